@@ -0,0 +1,75 @@
+package btree
+
+import (
+	"errors"
+
+	"golang.org/x/exp/slices"
+)
+
+// WriteBatch collects Put and Delete operations which are applied atomically by Apply.
+type WriteBatch[T Item] struct {
+	operations []*batchOperation[T]
+}
+
+// batchOperation deletes the key when item is nil.
+type batchOperation[T Item] struct {
+	key  KeyType
+	item *T
+}
+
+func NewWriteBatch[T Item]() *WriteBatch[T] {
+	return new(WriteBatch[T])
+}
+
+func (batch *WriteBatch[T]) Put(item *T) error {
+	if err := isValidStringLength(item); err != nil {
+		return err
+	}
+	batch.operations = append(batch.operations, &batchOperation[T]{key: (*item).GetKey(), item: item})
+	return nil
+}
+
+func (batch *WriteBatch[T]) Delete(key KeyType) {
+	batch.operations = append(batch.operations, &batchOperation[T]{key: key})
+}
+
+func (batch *WriteBatch[T]) Len() int {
+	return len(batch.operations)
+}
+
+// Apply applies operations of batch in ascending order of keys as a single operation. Operations with the same key
+// are applied in the order they were added. If any of them fails, for example by deleting a missing key, none is applied.
+// Nodes are decoded once and shared between traversals, and each node is written once when the batch is committed.
+func (btree *BTree[T]) Apply(batch *WriteBatch[T]) error {
+	if btree.isReadOnly {
+		return &ReadOnlyError{Operation: "Apply"}
+	}
+	btree.writeLock.Lock()
+	defer btree.writeLock.Unlock()
+	if !btree.isOpen {
+		return errors.New("Tree is closed")
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	operations := append([]*batchOperation[T]{}, batch.operations...)
+	slices.SortStableFunc(operations, func(a, b *batchOperation[T]) bool {
+		return a.key < b.key
+	})
+	return btree.operate(func(tree *BTree[T]) error {
+		tree.nodeCache = map[OffsetType]*Node[T]{}
+		for _, operation := range operations {
+			var err error
+			if operation.item != nil {
+				err = tree.put(operation.item)
+			} else {
+				err = tree.remove(operation.key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBatch(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("Apply -> Reopen with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+			random := rand.New(rand.NewSource(0))
+
+			btree, err := New[Sample](path, 3, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			for i := 0; i < 200; i += 2 {
+				btree.Put(&Sample{Int: i})
+			}
+
+			batch := NewWriteBatch[Sample]()
+			for _, key := range random.Perm(100) {
+				if err = batch.Put(&Sample{Int: key*2 + 1}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			for _, key := range random.Perm(50) {
+				batch.Delete(KeyType(key * 4))
+			}
+			commitCount := btree.commitCount
+			if err = btree.Apply(batch); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			if btree.commitCount != commitCount+1 {
+				t.Errorf("Batch should be committed at once")
+			}
+			btree.Close()
+
+			btree, err = New[Sample](path, 3, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+			for i := 0; i < 200; i++ {
+				_, err = btree.Get(KeyType(i))
+				if (err == nil) != (i%4 != 0) {
+					t.Errorf("Item %d should be found only if it is not deleted", i)
+				}
+			}
+			checkTree(t, btree)
+		})
+	}
+	t.Run("Operations with the same key are applied in order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		btree.Put(&Sample{Int: 1})
+
+		batch := NewWriteBatch[Sample]()
+		batch.Put(&Sample{Int: 2, String: "first"})
+		batch.Delete(1)
+		batch.Put(&Sample{Int: 2, String: "second"})
+		batch.Put(&Sample{Int: 1, String: "again"})
+		if err := btree.Apply(batch); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if item, err := btree.Get(1); err != nil || item.String != "again" {
+			t.Errorf("Item put after delete should be found")
+		}
+		if item, err := btree.Get(2); err != nil || item.String != "second" {
+			t.Errorf("Item put last should be found")
+		}
+	})
+	t.Run("Failed batch is not applied", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 50; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		pages := readPages(t, btree)
+
+		batch := NewWriteBatch[Sample]()
+		for i := 50; i < 100; i++ {
+			batch.Put(&Sample{Int: i})
+		}
+		batch.Delete(1000)
+		if err := btree.Apply(batch); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if len(readPages(t, btree)) != len(pages) {
+			t.Errorf("Data file should not be modified")
+		}
+		if _, err := btree.Get(60); err == nil {
+			t.Errorf("Item of failed batch should not be found")
+		}
+		checkTree(t, btree)
+	})
+	t.Run("Invalid operations", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		btree.Close()
+
+		batch := NewWriteBatch[Sample]()
+		batch.Put(&Sample{Int: 1})
+		if err := btree.Apply(batch); err == nil {
+			t.Errorf("Error should be raised")
+		}
+
+		btree, _ = OpenReadOnly[Sample](path)
+		defer btree.Close()
+		if err := btree.Apply(batch); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("ReadOnlyError should be raised")
+		}
+	})
+}
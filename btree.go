@@ -5,22 +5,81 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 )
 
 type BTree[T Item] struct {
-	path     string
-	isOpen   bool
-	degree   int
-	nodeSize int
-	fp       *os.File
+	path       string
+	isOpen     bool
+	degree     int
+	pageSize   int
+	layout     int
+	endOffset  OffsetType
+	journal    int
+	isDirect   bool
+	isReadOnly bool
+	syncPolicy int
+	header     *header
+	fp         file
+	wal        *wal
+	snapshots  map[*Snapshot[T]]bool
+
+	// State of the operation run by a fork, which is nil in the tree shared by readers
+	*operation[T]
+
+	// Writers build changes in a fork while readers hold lock, which is locked exclusively only while
+	// the changes are committed. Latched writers share writeLock and the others hold it exclusively.
+	lock      sync.RWMutex
+	writeLock sync.RWMutex
+
+	stopFlusher chan struct{}
+
+	// States of latched writers
+	latches        *latchTable
+	commitQueue    []*commitRequest[T]
+	exclusiveQueue []*exclusiveRequest[T]
+	groupLock      sync.Mutex
+	commitLock     sync.Mutex
+	commitCount    int
+
+	// States of copy-on-write mode
+	freePages          []OffsetType
+	heldFrees          []heldPage
+	syncedTxid         uint64
+	committedEndOffset OffsetType
 }
 
-func New[T Item](path string, degree int) (*BTree[T], error) {
+// operation holds pages and latches of a single operation, which are discarded or committed together.
+type operation[T Item] struct {
+	dirtyPages map[OffsetType][]byte
+	writeCount int
+	nodeCache  map[OffsetType]*Node[T]
+
+	// States of latched writers
+	heldLatches []OffsetType
+	isSafe      func(tree *BTree[T], node *Node[T]) bool
+
+	// States of copy-on-write mode
+	cleanPages     map[OffsetType][]byte
+	allocatedPages map[OffsetType]bool
+	pendingFrees   []OffsetType
+}
+
+func newOperation[T Item]() *operation[T] {
+	operation := new(operation[T])
+	operation.dirtyPages = map[OffsetType][]byte{}
+	operation.allocatedPages = map[OffsetType]bool{}
+	return operation
+}
+
+// New opens the data file at path or creates it. Page size is given by WithPageSize, otherwise the page size
+// of the existing file or the smallest power of 2 from DEFAULT_PAGE_SIZE which fits the largest item is used.
+func New[T Item](path string, degree int, opts ...Option) (*BTree[T], error) {
 	if path == "" {
 		return nil, errors.New("Parameter 'path' should not be empty")
 	}
-	if degree <= 1 {
-		return nil, errors.New("Parameter 'degree' should be greater than 1")
+	if degree < 0 || degree == 1 {
+		return nil, errors.New("Parameter 'degree' should be 0 or greater than 1")
 	}
 	if err := isValidItemFields[T](); err != nil {
 		return nil, err
@@ -28,35 +87,116 @@ func New[T Item](path string, degree int) (*BTree[T], error) {
 	if err := isValidStringLabel[T](); err != nil {
 		return nil, err
 	}
+	options, err := newOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	btree := new(BTree[T])
 	btree.path = path
 	btree.degree = degree
+	btree.layout = options.layout
+	btree.journal = options.journal
+	btree.isDirect = options.isDirect
+	btree.syncPolicy = options.syncPolicy
+	btree.snapshots = map[*Snapshot[T]]bool{}
+	btree.latches = newLatchTable()
 
 	fp, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0660)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("Failed to open or create data file at %s", path))
 	}
 	btree.fp = fp
+	// The lock is taken before the WAL is replayed so that another process never sees a partially applied operation
+	if err = lockFile(fp, false, options.lockTimeout); err != nil {
+		fp.Close()
+		return nil, err
+	}
+	// Trees with direct writes are not forked, so they keep the state of operations by themselves
+	if btree.isDirect {
+		btree.operation = newOperation[T]()
+	}
+	if !btree.isDirect && !btree.isCopyOnWrite() {
+		if btree.wal, err = openWAL(path + WAL_PATH_SUFFIX); err == nil {
+			err = btree.recover()
+		}
+		if err != nil {
+			btree.close()
+			return nil, err
+		}
+	}
 	btree.isOpen = true
 
 	if btree.getLastOffset() == 0 {
-		if err = btree.writeRootOffsetToDisk(OFFSET_SIZE_BYTE); err != nil {
+		btree.pageSize = options.pageSize
+		if btree.pageSize == 0 {
+			// Page size is doubled from the default until the largest item fits
+			btree.pageSize = DEFAULT_PAGE_SIZE
+			for btree.pageSize < MAX_PAGE_SIZE && btree.isValidPageSize() != nil {
+				btree.pageSize *= 2
+			}
+		}
+		if err = btree.isValidPageSize(); err != nil {
+			btree.close()
 			return nil, err
 		}
 
-		rootNode := newNode[T](OFFSET_SIZE_BYTE)
-		if err = btree.writeNodeToDisk(rootNode); err != nil {
+		// Each header slot occupies a whole page so that every node is aligned to page boundary
+		btree.header = newHeader[T](degree, btree.pageSize, btree.layout, btree.journal)
+		btree.endOffset = btree.firstPageOffset() + OffsetType(btree.pageSize)
+		err = btree.operate(func(tree *BTree[T]) error {
+			if err := tree.writeRootOffsetToDisk(tree.firstPageOffset()); err != nil {
+				return err
+			}
+			return tree.writeNodeToDisk(newNode[T](tree.firstPageOffset()))
+		})
+		if err != nil {
+			btree.close()
 			return nil, err
 		}
+	} else {
+		header, err := btree.readHeaderFromDisk()
+		if err == nil {
+			btree.pageSize = options.pageSize
+			if btree.pageSize == 0 && isValidPageSizeValue(int(header.pageSize)) {
+				btree.pageSize = int(header.pageSize)
+			} else if btree.pageSize == 0 {
+				btree.pageSize = DEFAULT_PAGE_SIZE
+			}
+			err = header.validate(newHeader[T](degree, btree.pageSize, btree.layout, btree.journal))
+		}
+		if err == nil {
+			err = btree.isValidPageSize()
+		}
+		if err != nil {
+			btree.close()
+			return nil, err
+		}
+		btree.header = header
+		btree.endOffset = btree.getLastOffset()
+		btree.committedEndOffset = btree.endOffset
+		if btree.isCopyOnWrite() {
+			// Pages unreachable from the header are reused, so the header is synced in case the last process did not
+			btree.syncedTxid = header.txid
+			if err = btree.fp.Sync(); err == nil {
+				err = btree.loadFreePages()
+			}
+			if err != nil {
+				btree.close()
+				return nil, err
+			}
+		}
 	}
 
-	btree.nodeSize = nodSizeByte[T](btree.maxElements())
-
+	if btree.syncPolicy == SYNC_INTERVAL {
+		btree.startFlusher(options.syncInterval)
+	}
 	return btree, nil
 }
 
 func (btree *BTree[T]) Show() error {
+	btree.lock.RLock()
+	defer btree.lock.RUnlock()
 	if !btree.isOpen {
 		return errors.New("Tree is closed")
 	}
@@ -67,10 +207,83 @@ func (btree *BTree[T]) Show() error {
 }
 
 func (btree *BTree[T]) Get(key KeyType) (*T, error) {
+	btree.lock.RLock()
+	defer btree.lock.RUnlock()
 	if !btree.isOpen {
 		return nil, errors.New("Tree is closed")
 	}
+	return btree.get(key)
+}
 
+// Put waits until a transaction in progress is finished.
+func (btree *BTree[T]) Put(item *T) error {
+	if btree.isReadOnly {
+		return &ReadOnlyError{Operation: "Put"}
+	}
+	if err := isValidStringLength(item); err != nil {
+		return err
+	}
+	return btree.write(func(tree *BTree[T]) error {
+		return tree.put(item)
+	}, (*BTree[T]).isSafeForPut)
+}
+
+// Delete waits until a transaction in progress is finished.
+func (btree *BTree[T]) Delete(key KeyType) error {
+	if btree.isReadOnly {
+		return &ReadOnlyError{Operation: "Delete"}
+	}
+	return btree.write(func(tree *BTree[T]) error {
+		return tree.remove(key)
+	}, (*BTree[T]).isSafeForDelete)
+}
+
+// Close waits until a transaction in progress is finished. Snapshots can be used after the tree is closed.
+func (btree *BTree[T]) Close() error {
+	btree.writeLock.Lock()
+	defer btree.writeLock.Unlock()
+	btree.lock.Lock()
+	defer btree.lock.Unlock()
+	if !btree.isOpen {
+		return errors.New("Tree is already closed")
+	}
+	if btree.stopFlusher != nil {
+		close(btree.stopFlusher)
+		// Operations since the last flush are synced unlike with SYNC_NEVER
+		btree.checkpoint()
+	}
+	err := btree.close()
+	if err != nil {
+		return err
+	}
+	btree.isOpen = false
+	return nil
+}
+
+// write runs fn with latches in parallel with other writers, and runs it again with exclusive access
+// if it is restarted. Every write runs with exclusive access in copy-on-write mode, where a commit
+// always modifies the root.
+func (btree *BTree[T]) write(fn func(tree *BTree[T]) error, isSafe func(tree *BTree[T], node *Node[T]) bool) error {
+	if !btree.isCopyOnWrite() {
+		btree.writeLock.RLock()
+		err := btree.operateLatched(fn, isSafe)
+		btree.writeLock.RUnlock()
+		if err != errRestart {
+			return err
+		}
+	}
+
+	return btree.operateExclusive(fn)
+}
+
+func (btree *BTree[T]) close() error {
+	if btree.wal != nil {
+		btree.wal.close()
+	}
+	return btree.fp.Close()
+}
+
+func (btree *BTree[T]) get(key KeyType) (*T, error) {
 	isFound, traversedNodes, traversedIndices, err := btree.traverse(key)
 	if err != nil {
 		return nil, err
@@ -85,19 +298,21 @@ func (btree *BTree[T]) Get(key KeyType) (*T, error) {
 	if element.isClosed {
 		return nil, errors.New(fmt.Sprintf("Item with key %d is not found", key))
 	}
+	if err = btree.loadOverflows(element); err != nil {
+		return nil, err
+	}
 	return element.item, nil
 }
 
-func (btree *BTree[T]) Put(item *T) error {
-	if !btree.isOpen {
-		return errors.New("Tree is closed")
-	}
-
+func (btree *BTree[T]) put(item *T) error {
 	element := newElement(item)
 	isFound, traversedNodes, traversedIndices, err := btree.traverse(element.getKey())
 	if err != nil {
 		return err
 	}
+	if err = btree.spillOverflows(element); err != nil {
+		return err
+	}
 	if isFound {
 		return btree.update(element, traversedNodes, traversedIndices)
 	} else {
@@ -105,10 +320,7 @@ func (btree *BTree[T]) Put(item *T) error {
 	}
 }
 
-func (btree *BTree[T]) Delete(key KeyType) error {
-	if !btree.isOpen {
-		return errors.New("Tree is closed")
-	}
+func (btree *BTree[T]) remove(key KeyType) error {
 	isFound, traversedNodes, traversedIndices, err := btree.traverse(key)
 	if err != nil {
 		return err
@@ -116,51 +328,97 @@ func (btree *BTree[T]) Delete(key KeyType) error {
 	if !isFound {
 		return errors.New(fmt.Sprintf("Item with key %d is not found", key))
 	}
-
-	node := traversedNodes[len(traversedNodes)-1]
-	index := traversedIndices[len(traversedNodes)-1]
-	element := node.elements[index]
-
-	element.isClosed = true
-	return btree.writeNodeToDisk(node)
+	return btree.delete(traversedNodes, traversedIndices)
 }
 
-func (btree *BTree[T]) Close() error {
-	if !btree.isOpen {
-		return errors.New("Tree is already closed")
-	}
-	err := btree.fp.Close()
+func (btree *BTree[T]) show(offset OffsetType, isRoot bool) error {
+	node, err := btree.readNodeFromDisk(offset)
 	if err != nil {
 		return err
 	}
-	btree.isOpen = false
+	node.print(offset, isRoot)
+	for _, childOffset := range node.childOffsets {
+		if err = btree.show(childOffset, false); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (btree *BTree[T]) show(offset OffsetType, isRoot bool) error {
+// walk calls fn for every element under the node at offset in ascending order of keys.
+// Key only elements in internal nodes of B+tree are skipped.
+func (btree *BTree[T]) walk(offset OffsetType, fn func(*Element[T]) error) error {
 	node, err := btree.readNodeFromDisk(offset)
 	if err != nil {
 		return err
 	}
-	node.print(offset, isRoot)
-	for _, childOffset := range node.childOffsets {
-		if err = btree.show(childOffset, false); err != nil {
+	if btree.isBPlusTree() {
+		return btree.walkLeaves(node, fn)
+	}
+	for i, element := range node.elements {
+		if !node.isLeaf() {
+			if err = btree.walk(node.childOffsets[i], fn); err != nil {
+				return err
+			}
+		}
+		if err = fn(element); err != nil {
 			return err
 		}
 	}
+	if !node.isLeaf() {
+		return btree.walk(node.childOffsets[len(node.childOffsets)-1], fn)
+	}
 	return nil
 }
 
+// walkLeaves descends to the leftmost leaf under node and follows links between leaf nodes.
+// Leaves of other subtrees are not visited since it stops at the last leaf under node.
+func (btree *BTree[T]) walkLeaves(node *Node[T], fn func(*Element[T]) error) error {
+	lastNode := node
+	var err error
+	for !lastNode.isLeaf() {
+		if lastNode, err = btree.readNodeFromDisk(lastNode.childOffsets[len(lastNode.childOffsets)-1]); err != nil {
+			return err
+		}
+	}
+	for !node.isLeaf() {
+		if node, err = btree.readNodeFromDisk(node.childOffsets[0]); err != nil {
+			return err
+		}
+	}
+	for {
+		for _, element := range node.elements {
+			if err = fn(element); err != nil {
+				return err
+			}
+		}
+		if node.offset == lastNode.offset || node.nextOffset == 0 {
+			return nil
+		}
+		if node, err = btree.readNodeFromDisk(node.nextOffset); err != nil {
+			return err
+		}
+	}
+}
+
 func (btree *BTree[T]) update(element *Element[T], traversedNodes []*Node[T], traversedIndices []int) error {
 	numberOfTraverse := len(traversedNodes)
 	node := traversedNodes[numberOfTraverse-1]
 	index := traversedIndices[numberOfTraverse-1]
 
+	oldElement := node.elements[index]
 	node.elements[index] = element
-	if err := btree.writeNodeToDisk(node); err != nil {
+	// Node may not fit in a page, or be under populated, when the element is replaced by one of different size
+	var err error
+	if numberOfTraverse > 1 && btree.isUnderPopulated(node) {
+		err = btree.rebalance(traversedNodes, traversedIndices, -1)
+	} else {
+		err = btree.splitNodes(traversedNodes, traversedIndices)
+	}
+	if err != nil {
 		return err
 	}
-	return nil
+	return btree.freeOverflows(oldElement)
 }
 
 func (btree *BTree[T]) insert(element *Element[T], traversedNodes []*Node[T], traversedIndices []int) error {
@@ -169,9 +427,13 @@ func (btree *BTree[T]) insert(element *Element[T], traversedNodes []*Node[T], tr
 
 	// Insert element to leaf node
 	leafNode.insertElement(element, leafNodeIndex)
-	if !leafNode.isOverPopulated(btree.maxElements()) {
-		btree.writeNodeToDisk(leafNode)
-		return nil
+	return btree.splitNodes(traversedNodes, traversedIndices)
+}
+
+// splitNodes writes the last traversed node, splitting it and its ancestors while they are over populated.
+func (btree *BTree[T]) splitNodes(traversedNodes []*Node[T], traversedIndices []int) error {
+	if !btree.isOverPopulated(traversedNodes[len(traversedNodes)-1]) {
+		return btree.writeNodeToDisk(traversedNodes[len(traversedNodes)-1])
 	}
 
 	// Split non-root nodes
@@ -180,14 +442,23 @@ func (btree *BTree[T]) insert(element *Element[T], traversedNodes []*Node[T], tr
 		parentNode := traversedNodes[i-1]
 		parentNodeIndex := traversedIndices[i-1]
 
-		if node.isOverPopulated(btree.maxElements()) {
-			newOffset := btree.getLastOffset()
+		if btree.isOverPopulated(node) {
+			newOffset, err := btree.allocate()
+			if err != nil {
+				return err
+			}
 			newNode := btree.split(node, parentNode, parentNodeIndex, newOffset)
-			btree.writeNodeToDisk(node)
-			btree.writeNodeToDisk(newNode)
-			if !parentNode.isOverPopulated(btree.maxElements()) {
+			if err = btree.writeNodesToDisk(node, newNode); err != nil {
+				return err
+			}
+			if err = btree.linkNextLeaf(newNode); err != nil {
+				return err
+			}
+			if !btree.isOverPopulated(parentNode) {
 				// If parent node is over populated, it should be processed in the next loop
-				btree.writeNodeToDisk(parentNode)
+				if err = btree.writeNodeToDisk(parentNode); err != nil {
+					return err
+				}
 			}
 		} else {
 			// If node is not over populated, following nodes are also not populated
@@ -195,33 +466,183 @@ func (btree *BTree[T]) insert(element *Element[T], traversedNodes []*Node[T], tr
 		}
 	}
 
-	// Split root node
 	rootNode := traversedNodes[0]
-	if rootNode.isOverPopulated(btree.maxElements()) {
-		newRootNodeOffset := btree.getLastOffset()
-		newRootNode := newNode[T](newRootNodeOffset)
-		newRootNode.childOffsets = []OffsetType{rootNode.offset}
+	if btree.isOverPopulated(rootNode) {
+		return btree.splitRoot(rootNode)
+	}
+	return nil
+}
 
-		newNodeOffset := newRootNodeOffset + OffsetType(nodSizeByte[T](btree.maxElements()))
-		newNode := btree.split(rootNode, newRootNode, 0, newNodeOffset)
+// splitRoot splits root node into two children of a new root node.
+func (btree *BTree[T]) splitRoot(rootNode *Node[T]) error {
+	newRootNodeOffset, err := btree.allocate()
+	if err != nil {
+		return err
+	}
+	newRootNode := newNode[T](newRootNodeOffset)
+	newRootNode.childOffsets = []OffsetType{rootNode.offset}
 
-		btree.writeRootOffsetToDisk(newRootNodeOffset)
-		btree.writeNodeToDisk(newRootNode)
-		btree.writeNodeToDisk(rootNode)
-		btree.writeNodeToDisk(newNode)
+	newNodeOffset, err := btree.allocate()
+	if err != nil {
+		return err
 	}
-	return nil
+	newNode := btree.split(rootNode, newRootNode, 0, newNodeOffset)
+
+	if err = btree.writeRootOffsetToDisk(newRootNodeOffset); err != nil {
+		return err
+	}
+	return btree.writeNodesToDisk(newRootNode, rootNode, newNode)
 }
 
+func (btree *BTree[T]) delete(traversedNodes []*Node[T], traversedIndices []int) error {
+	node := traversedNodes[len(traversedNodes)-1]
+	index := traversedIndices[len(traversedNodes)-1]
+
+	deletedElement := node.elements[index]
+	if node.isLeaf() {
+		node.removeElement(index)
+		if err := btree.rebalance(traversedNodes, traversedIndices, -1); err != nil {
+			return err
+		}
+		return btree.freeOverflows(deletedElement)
+	}
+
+	// Replace element of internal node with its predecessor, the largest element in the left subtree.
+	// Internal node is written by rebalance since it may not fit in a page with the predecessor
+	modifiedDepth := len(traversedNodes) - 1
+	childNode, err := btree.readNodeFromDisk(node.childOffsets[index])
+	if err != nil {
+		return err
+	}
+	for !childNode.isLeaf() {
+		traversedNodes = append(traversedNodes, childNode)
+		traversedIndices = append(traversedIndices, len(childNode.childOffsets)-1)
+		if childNode, err = btree.readNodeFromDisk(childNode.childOffsets[len(childNode.childOffsets)-1]); err != nil {
+			return err
+		}
+	}
+	traversedNodes = append(traversedNodes, childNode)
+	traversedIndices = append(traversedIndices, len(childNode.elements)-1)
+
+	node.elements[index] = childNode.removeElement(len(childNode.elements) - 1)
+	if err = btree.rebalance(traversedNodes, traversedIndices, modifiedDepth); err != nil {
+		return err
+	}
+	return btree.freeOverflows(deletedElement)
+}
+
+// rebalance fixes under and over populated nodes from the bottom of traversed nodes towards the root.
+// Under populated node is merged with its sibling, and merged node is split again
+// to redistribute elements evenly if it does not fit in a page. Over populated node, whose element
+// was replaced by a larger one, is split. Nodes above modifiedDepth, or -1, are not modified before rebalance,
+// so that it stops at the first balanced node above it.
+func (btree *BTree[T]) rebalance(traversedNodes []*Node[T], traversedIndices []int, modifiedDepth int) error {
+	for i := len(traversedNodes) - 1; i > 0; i-- {
+		node := traversedNodes[i]
+		if btree.isOverPopulated(node) {
+			newOffset, err := btree.allocate()
+			if err != nil {
+				return err
+			}
+			newNode := btree.split(node, traversedNodes[i-1], traversedIndices[i-1], newOffset)
+			if err = btree.writeNodesToDisk(node, newNode); err != nil {
+				return err
+			}
+			if err = btree.linkNextLeaf(newNode); err != nil {
+				return err
+			}
+			continue
+		}
+		if !btree.isUnderPopulated(node) {
+			if err := btree.writeNodeToDisk(node); err != nil {
+				return err
+			}
+			if i <= modifiedDepth || modifiedDepth < 0 {
+				return nil
+			}
+			// Nodes between are not modified
+			i = modifiedDepth + 1
+			continue
+		}
+
+		parentNode := traversedNodes[i-1]
+		separatorIndex := traversedIndices[i-1]
+		leftNode, rightNode := node, node
+		var err error
+		if separatorIndex == len(parentNode.childOffsets)-1 {
+			separatorIndex -= 1
+			leftNode, err = btree.readNodeFromDisk(parentNode.childOffsets[separatorIndex])
+		} else {
+			rightNode, err = btree.readNodeFromDisk(parentNode.childOffsets[separatorIndex+1])
+		}
+		if err != nil {
+			return err
+		}
+
+		if btree.isBPlusTree() && leftNode.isLeaf() {
+			leftNode.mergeLeaf(rightNode, parentNode, separatorIndex)
+		} else {
+			leftNode.merge(rightNode, parentNode, separatorIndex)
+		}
+		if btree.isOverPopulated(leftNode) {
+			// Parent node is written or split in the next loop since the new separator may be larger
+			rightNode = btree.split(leftNode, parentNode, separatorIndex, rightNode.offset)
+			if err = btree.writeNodesToDisk(leftNode, rightNode); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Merged parent node is written or rebalanced in the next loop
+		if err = btree.writeNodeToDisk(leftNode); err != nil {
+			return err
+		}
+		if err = btree.linkNextLeaf(leftNode); err != nil {
+			return err
+		}
+		if err = btree.free(rightNode.offset); err != nil {
+			return err
+		}
+	}
+
+	// Shrink the tree when root node has lost its last element
+	rootNode := traversedNodes[0]
+	if btree.isOverPopulated(rootNode) {
+		return btree.splitRoot(rootNode)
+	}
+	if len(rootNode.elements) == 0 && !rootNode.isLeaf() {
+		if err := btree.writeRootOffsetToDisk(rootNode.childOffsets[0]); err != nil {
+			return err
+		}
+		return btree.free(rootNode.offset)
+	}
+	return btree.writeNodeToDisk(rootNode)
+}
+
+// split moves the latter half of node into a new node at newNodeOffset and inserts the separator into parentNode.
+// Leaf node of B+tree keeps the middle element and only its key is copied to parentNode.
+// Previous link of the node next to the new node is fixed by linkNextLeaf.
 func (btree *BTree[T]) split(node *Node[T], parentNode *Node[T], parentIndex int, newNodeOffset OffsetType) *Node[T] {
-	middleElement := node.elements[btree.minElements()]
+	middle := btree.splitIndex(node)
+	middleElement := node.elements[middle]
 	newNode := newNode[T](newNodeOffset)
 
-	newNode.elements = node.elements[btree.minElements()+1:]
-	node.elements = node.elements[:btree.minElements()]
+	if btree.isBPlusTree() && node.isLeaf() {
+		newNode.elements = append([]*Element[T]{}, node.elements[middle:]...)
+		node.elements = node.elements[:middle]
+		newNode.prevOffset = node.offset
+		newNode.nextOffset = node.nextOffset
+		node.nextOffset = newNodeOffset
+		parentNode.insertElement(newKeyElement[T](middleElement.getKey()), parentIndex)
+		parentNode.insertChildOffset(newNodeOffset, parentIndex+1)
+		return newNode
+	}
+
+	newNode.elements = append([]*Element[T]{}, node.elements[middle+1:]...)
+	node.elements = node.elements[:middle]
 	if !node.isLeaf() {
-		newNode.childOffsets = node.childOffsets[btree.minElements()+1:]
-		node.childOffsets = node.childOffsets[:btree.minElements()+1]
+		newNode.childOffsets = append([]OffsetType{}, node.childOffsets[middle+1:]...)
+		node.childOffsets = node.childOffsets[:middle+1]
 	}
 	parentNode.insertElement(middleElement, parentIndex)
 	parentNode.insertChildOffset(newNodeOffset, parentIndex+1)
@@ -229,18 +650,59 @@ func (btree *BTree[T]) split(node *Node[T], parentNode *Node[T], parentIndex int
 	return newNode
 }
 
+// linkNextLeaf points the previous link of the leaf next to node at node.
+func (btree *BTree[T]) linkNextLeaf(node *Node[T]) error {
+	if !btree.isBPlusTree() || !node.isLeaf() || node.nextOffset == 0 {
+		return nil
+	}
+	nextNode, err := btree.readNodeFromDisk(node.nextOffset)
+	if err != nil {
+		return err
+	}
+	if nextNode.prevOffset == node.offset {
+		return nil
+	}
+	nextNode.prevOffset = node.offset
+	return btree.writeNodeToDisk(nextNode)
+}
+
+// linkPrevLeaf points the next link of the leaf previous to node at node.
+func (btree *BTree[T]) linkPrevLeaf(node *Node[T]) error {
+	if !btree.isBPlusTree() || !node.isLeaf() || node.prevOffset == 0 {
+		return nil
+	}
+	prevNode, err := btree.readNodeFromDisk(node.prevOffset)
+	if err != nil {
+		return err
+	}
+	if prevNode.nextOffset == node.offset {
+		return nil
+	}
+	prevNode.nextOffset = node.offset
+	return btree.writeNodeToDisk(prevNode)
+}
+
+// traverse returns nodes and indices from the root to the node which has the key.
+// In B+tree, it always reaches a leaf node since keys in internal nodes are only separators
+// and items with the same key as a separator are in its right subtree.
 func (btree *BTree[T]) traverse(key KeyType) (bool, []*Node[T], []int, error) {
 	traversedNodes := make([]*Node[T], 0)
 	traversedIndices := make([]int, 0)
 
 	offset := btree.getRootOffset()
+	btree.latchNode(offset)
 	node, err := btree.readNodeFromDisk(offset)
 	if err != nil {
 		return false, nil, nil, err
 	}
+	btree.releaseAncestors(node)
 
 	isFound, index := node.traverse(key)
 	for {
+		if isFound && btree.isBPlusTree() && !node.isLeaf() {
+			isFound = false
+			index += 1
+		}
 		traversedNodes = append(traversedNodes, node)
 		traversedIndices = append(traversedIndices, index)
 
@@ -251,14 +713,87 @@ func (btree *BTree[T]) traverse(key KeyType) (bool, []*Node[T], []int, error) {
 			return false, traversedNodes, traversedIndices, nil
 		}
 
+		btree.latchNode(node.childOffsets[index])
 		node, err = btree.readNodeFromDisk(node.childOffsets[index])
 		if err != nil {
 			return false, nil, nil, err
 		}
+		btree.releaseAncestors(node)
 		isFound, index = node.traverse(key)
 	}
 }
 
+// isOverPopulated reports whether node has more elements than degree allows or does not fit in a page.
+func (btree *BTree[T]) isOverPopulated(node *Node[T]) bool {
+	if btree.degree != PAGE_DEGREE && len(node.elements) > btree.maxElements()-1 {
+		return true
+	}
+	return node.bodySizeByte() > btree.nodeCapacity()
+}
+
+// isUnderPopulated reports whether node has fewer elements than degree requires,
+// or uses less than a quarter of a page when the tree has no degree.
+func (btree *BTree[T]) isUnderPopulated(node *Node[T]) bool {
+	if btree.degree != PAGE_DEGREE {
+		return len(node.elements) < btree.minElements()
+	}
+	return node.bodySizeByte() < btree.nodeCapacity()/4
+}
+
+// splitIndex returns index of the element which divides node into two halves of similar size.
+func (btree *BTree[T]) splitIndex(node *Node[T]) int {
+	if btree.degree != PAGE_DEGREE {
+		return len(node.elements) / 2
+	}
+
+	entrySizes := make([]int, len(node.elements))
+	total := 0
+	for i, element := range node.elements {
+		entrySizes[i] = SLOT_SIZE_BYTE + element.sizeByte()
+		if !node.isLeaf() {
+			entrySizes[i] += OFFSET_SIZE_BYTE
+		}
+		total += entrySizes[i]
+	}
+
+	middle := 1
+	left := entrySizes[0]
+	for i := 2; i < len(node.elements)-1; i++ {
+		// Move the middle to the right while the left half stays smaller than the right half
+		if left+entrySizes[middle] >= total-left-entrySizes[middle] {
+			break
+		}
+		left += entrySizes[middle]
+		middle = i
+	}
+	return middle
+}
+
+// nodeCapacity returns bytes available for child offsets, slots and elements in a page.
+func (btree *BTree[T]) nodeCapacity() int {
+	return btree.pageSize - NODE_HEADER_SIZE_BYTE - CHECKSUM_SIZE_BYTE
+}
+
+// isValidPageSize checks that a page can hold nodes of the largest items, so that a split always
+// produces two nodes which fit in a page.
+func (btree *BTree[T]) isValidPageSize() error {
+	largestEntrySize := SLOT_SIZE_BYTE + calElementSize[T]() + OFFSET_SIZE_BYTE
+	if btree.degree == PAGE_DEGREE {
+		if largestEntrySize*4 > btree.nodeCapacity() {
+			return errors.New(fmt.Sprintf("Item can be larger than a quarter of page size %d", btree.pageSize))
+		}
+		return nil
+	}
+	if largestEntrySize*(btree.maxElements()-1)+OFFSET_SIZE_BYTE > btree.nodeCapacity() {
+		return errors.New(fmt.Sprintf("Nodes with degree %d do not fit in page size %d", btree.degree, btree.pageSize))
+	}
+	return nil
+}
+
+func (btree *BTree[T]) isBPlusTree() bool {
+	return btree.layout == LAYOUT_BPLUS_TREE
+}
+
 func (btree *BTree[T]) minElements() int {
 	return btree.degree - 1
 }
@@ -273,44 +808,98 @@ func (btree *BTree[T]) getLastOffset() OffsetType {
 }
 
 func (btree *BTree[T]) getRootOffset() OffsetType {
-	btree.fp.Seek(0, 0)
-	buff := make([]byte, OFFSET_SIZE_BYTE)
-	btree.fp.Read(buff)
-	return OffsetType(binary.BigEndian.Uint64(buff))
+	return btree.header.rootOffset
 }
 
-func (btree *BTree[T]) readNodeFromDisk(offset OffsetType) (*Node[T], error) {
-	btree.fp.Seek(offset, 0)
-	nodeSize := nodSizeByte[T](btree.maxElements())
-	buff := make([]byte, nodeSize)
+// readHeaderFromDisk returns the header in the first slot, or the one in the second slot if it is newer.
+// The second slot is used only in copy-on-write mode, where the header of the last commit may be torn.
+func (btree *BTree[T]) readHeaderFromDisk() (*header, error) {
+	buff := make([]byte, HEADER_SIZE_BYTE)
+	if err := btree.readAt(buff, 0); err != nil {
+		return nil, &IncompatibleFileError{Field: "header size", Expected: HEADER_SIZE_BYTE, Actual: uint64(btree.getLastOffset())}
+	}
+	header := new(header)
+	header.deserialize(buff)
+	isValid := verifyChecksum(buff)
+
+	// The second slot is at the page size, which is unknown when the first slot is broken
+	slotOffsets := []OffsetType{OffsetType(header.pageSize)}
+	if !isValid {
+		slotOffsets = []OffsetType{}
+		for pageSize := MIN_PAGE_SIZE; pageSize <= MAX_PAGE_SIZE; pageSize *= 2 {
+			slotOffsets = append(slotOffsets, OffsetType(pageSize))
+		}
+	}
+	slotBuff := make([]byte, HEADER_SIZE_BYTE)
+	for _, slotOffset := range slotOffsets {
+		if err := btree.readAt(slotBuff, slotOffset); err != nil || !verifyChecksum(slotBuff) {
+			continue
+		}
+		slotHeader := *header
+		slotHeader.deserialize(slotBuff)
+		if slotHeader.magic != binary.BigEndian.Uint64([]byte(MAGIC)) || OffsetType(slotHeader.pageSize) != slotOffset {
+			continue
+		}
+		if !isValid || slotHeader.txid > header.txid {
+			return &slotHeader, nil
+		}
+		break
+	}
+	if header.magic == binary.BigEndian.Uint64([]byte(MAGIC)) && !isValid {
+		return nil, &CorruptedError{Offset: 0}
+	}
+	return header, nil
+}
 
-	btree.fp.Seek(offset, 0)
-	btree.fp.Read(buff)
+func (btree *BTree[T]) readNodeFromDisk(offset OffsetType) (*Node[T], error) {
+	if err := btree.checkLatch(offset); err != nil {
+		return nil, err
+	}
+	if btree.operation != nil {
+		if node, ok := btree.nodeCache[offset]; ok {
+			return node, nil
+		}
+	}
+	buff := make([]byte, btree.pageSize)
+	if err := btree.readAt(buff, offset); err != nil || !verifyChecksum(buff) || buff[0] != PAGE_TYPE_NODE {
+		return nil, &CorruptedError{Offset: offset}
+	}
 
 	node := newNode[T](offset)
-	node.deserialize(buff, btree.maxElements())
+	node.deserialize(buff)
+	if btree.operation != nil && btree.nodeCache != nil {
+		btree.nodeCache[offset] = node
+	}
 	return node, nil
 }
 
+// writeNodeToDisk also caches node when nodes are shared between operations of a batch,
+// where every node modified by an operation is written before the next one.
 func (btree *BTree[T]) writeNodeToDisk(node *Node[T]) error {
-	buff := node.serialize(btree.maxElements())
-	btree.fp.Seek(node.offset, 0)
-	_, err := btree.fp.Write(buff)
-	defer btree.fp.Sync()
-	if err != nil {
-		return err
+	if btree.nodeCache != nil {
+		btree.nodeCache[node.offset] = node
+	}
+	return btree.writeAt(appendChecksum(node.serialize(btree.pageSize)), node.offset)
+}
+
+func (btree *BTree[T]) writeNodesToDisk(nodes ...*Node[T]) error {
+	for _, node := range nodes {
+		if err := btree.writeNodeToDisk(node); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func (btree *BTree[T]) writeRootOffsetToDisk(rootOffset OffsetType) error {
-	btree.fp.Seek(0, 0)
-	buff := make([]byte, OFFSET_SIZE_BYTE)
-	binary.BigEndian.PutUint64(buff, uint64(rootOffset))
-	_, err := btree.fp.Write(buff)
-	defer btree.fp.Sync()
-	if err != nil {
-		return err
+	btree.header.rootOffset = rootOffset
+	return btree.writeHeaderToDisk()
+}
+
+// writeHeaderToDisk is deferred until the operation is committed in copy-on-write mode.
+func (btree *BTree[T]) writeHeaderToDisk() error {
+	if btree.isCopyOnWrite() && !btree.isDirect {
+		return nil
 	}
-	return nil
+	return btree.writeAt(btree.header.serialize(), 0)
 }
@@ -1,7 +1,13 @@
 package btree
 
 import (
+	"errors"
+	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -169,3 +175,500 @@ func TestBTree(t *testing.T) {
 		btree.Close()
 	})
 }
+
+func TestBTreeHeader(t *testing.T) {
+	t.Run("Reopen with the same parameters", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Errorf("Error should not be raised")
+		}
+		for i := 0; i < 20; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		btree.Close()
+
+		btree, err = New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 0; i < 20; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || item.Int != i {
+				t.Errorf("item.Int should be %d", i)
+			}
+		}
+		btree.Close()
+	})
+	t.Run("Reopen with different degree or item type", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Renamed](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Errorf("Error should not be raised")
+		}
+		btree.Close()
+
+		if _, err = New[Renamed](path, DEFAULT_DEGREE+1); !errors.Is(err, ErrIncompatibleFile) {
+			t.Errorf("ErrIncompatibleFile should be raised")
+		}
+		if _, err = New[Other](path, DEFAULT_DEGREE); !errors.Is(err, ErrIncompatibleFile) {
+			t.Errorf("ErrIncompatibleFile should be raised")
+		}
+	})
+	t.Run("Open file without header", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+		os.WriteFile(path, make([]byte, 1024), 0660)
+
+		var incompatibleFileError *IncompatibleFileError
+		_, err := New[Sample](path, DEFAULT_DEGREE)
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "magic" {
+			t.Errorf("IncompatibleFileError for magic should be raised")
+		}
+	})
+}
+
+func TestBTreeChecksum(t *testing.T) {
+	t.Run("Get from corrupted node", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		btree.Put(&Sample{Int: 1})
+		rootOffset := btree.getRootOffset()
+		btree.Close()
+
+		fp, _ := os.OpenFile(path, os.O_RDWR, 0660)
+		fp.WriteAt([]byte{0xff}, rootOffset+20)
+		fp.Close()
+
+		btree, err = New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		var corruptedError *CorruptedError
+		_, err = btree.Get(1)
+		if !errors.As(err, &corruptedError) || corruptedError.Offset != rootOffset {
+			t.Errorf("CorruptedError at root offset should be raised")
+		}
+		if !errors.Is(err, ErrCorrupted) {
+			t.Errorf("Error should be ErrCorrupted")
+		}
+	})
+	t.Run("Open with corrupted header", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		btree.Close()
+
+		fp, _ := os.OpenFile(path, os.O_RDWR, 0660)
+		fp.WriteAt([]byte{0xff}, 45)
+		fp.Close()
+
+		if _, err = New[Sample](path, DEFAULT_DEGREE); !errors.Is(err, ErrCorrupted) {
+			t.Errorf("ErrCorrupted should be raised")
+		}
+	})
+}
+
+// checkTree walks the whole tree and verifies ordering, balance and population of nodes.
+// It returns keys of all elements in order.
+func checkTree[T Item](t *testing.T, btree *BTree[T]) []KeyType {
+	keys := []KeyType{}
+	leaves := []*Node[T]{}
+	leafDepth := -1
+	var walk func(offset OffsetType, depth int, isRoot bool)
+	walk = func(offset OffsetType, depth int, isRoot bool) {
+		node, err := btree.readNodeFromDisk(offset)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if !isRoot && btree.isUnderPopulated(node) {
+			t.Errorf("Node at %d should not be under populated", offset)
+		}
+		if btree.isOverPopulated(node) {
+			t.Errorf("Node at %d should not be over populated", offset)
+		}
+		if btree.isBPlusTree() && len(node.elements) > 0 && node.isKeyOnly() == node.isLeaf() {
+			t.Errorf("Only internal nodes of B+tree should hold key only elements")
+		}
+		if node.isLeaf() {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				t.Errorf("Leaf at %d should be at depth %d", offset, leafDepth)
+			}
+			leaves = append(leaves, node)
+			for _, element := range node.elements {
+				keys = append(keys, element.getKey())
+			}
+			return
+		}
+		if len(node.childOffsets) != len(node.elements)+1 {
+			t.Errorf("Node at %d should have %d child offsets", offset, len(node.elements)+1)
+		}
+		for i, childOffset := range node.childOffsets {
+			walk(childOffset, depth+1, false)
+			if i < len(node.elements) && !btree.isBPlusTree() {
+				keys = append(keys, node.elements[i].getKey())
+			}
+		}
+	}
+	walk(btree.getRootOffset(), 0, true)
+	for i := 0; i < len(leaves) && btree.isBPlusTree(); i++ {
+		prevOffset, nextOffset := OffsetType(0), OffsetType(0)
+		if i > 0 {
+			prevOffset = leaves[i-1].offset
+		}
+		if i < len(leaves)-1 {
+			nextOffset = leaves[i+1].offset
+		}
+		if leaves[i].prevOffset != prevOffset || leaves[i].nextOffset != nextOffset {
+			t.Errorf("Leaf at %d should be linked to its siblings", leaves[i].offset)
+		}
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			t.Errorf("Keys should be in ascending order")
+		}
+	}
+	return keys
+}
+
+func TestBTreeDelete(t *testing.T) {
+	for _, degree := range []int{2, 3, 5, PAGE_DEGREE} {
+		t.Run(fmt.Sprintf("Put -> Delete in random order with degree %d", degree), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+			random := rand.New(rand.NewSource(int64(degree)))
+
+			btree, err := New[Sample](path, degree)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+
+			keys := random.Perm(300)
+			for _, key := range keys {
+				if err = btree.Put(&Sample{Int: key, String: strings.Repeat("x", random.Intn(DEFAULT_STRING_MAX_LENGTH))}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			if len(checkTree(t, btree)) != len(keys) {
+				t.Errorf("Tree should have %d keys", len(keys))
+			}
+
+			random.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+			for i, key := range keys {
+				if err = btree.Delete(KeyType(key)); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+				if _, err = btree.Get(KeyType(key)); err == nil {
+					t.Errorf("Error should be raised")
+				}
+				if i%25 == 0 {
+					if len(checkTree(t, btree)) != len(keys)-i-1 {
+						t.Errorf("Tree should have %d keys", len(keys)-i-1)
+					}
+				}
+			}
+			if len(checkTree(t, btree)) != 0 {
+				t.Errorf("Tree should be empty")
+			}
+			rootNode, _ := btree.readNodeFromDisk(btree.getRootOffset())
+			if !rootNode.isLeaf() {
+				t.Errorf("Root node should be leaf")
+			}
+		})
+	}
+	t.Run("Delete missing key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		btree.Put(&Sample{Int: 1})
+		if err := btree.Delete(2); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
+
+func TestBPlusTree(t *testing.T) {
+	for _, degree := range []int{2, 3, 5, PAGE_DEGREE} {
+		t.Run(fmt.Sprintf("Put -> Delete -> Vacuum in random order with degree %d", degree), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+			random := rand.New(rand.NewSource(int64(degree)))
+
+			btree, err := New[Sample](path, degree, WithBPlusTree())
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+
+			for _, key := range random.Perm(300) {
+				if err = btree.Put(&Sample{Int: key, String: strings.Repeat("x", random.Intn(DEFAULT_STRING_MAX_LENGTH))}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			if keys := checkTree(t, btree); len(keys) != 300 {
+				t.Errorf("All items should be in leaf nodes")
+			}
+
+			for _, key := range random.Perm(300)[:200] {
+				if err = btree.Delete(KeyType(key)); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			keys := checkTree(t, btree)
+			if len(keys) != 100 {
+				t.Errorf("Deleted items should be removed from leaf nodes")
+			}
+			for _, key := range keys {
+				if item, err := btree.Get(key); err != nil || KeyType(item.Int) != key {
+					t.Errorf("Item %d should be found", key)
+				}
+			}
+
+			for {
+				if count, err := btree.IncrementalVacuum(16); err != nil || count == 0 {
+					break
+				}
+			}
+			if fmt.Sprint(checkTree(t, btree)) != fmt.Sprint(keys) {
+				t.Errorf("Items should be kept by IncrementalVacuum")
+			}
+			if err = btree.Compact(1); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			if fmt.Sprint(checkTree(t, btree)) != fmt.Sprint(keys) {
+				t.Errorf("Items should be kept by Compact")
+			}
+		})
+	}
+	t.Run("Reopen with different layout", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE, WithBPlusTree())
+		btree.Close()
+
+		var incompatibleFileError *IncompatibleFileError
+		_, err := New[Sample](path, DEFAULT_DEGREE)
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "layout" {
+			t.Errorf("IncompatibleFileError for layout should be raised")
+		}
+	})
+}
+
+func TestBTreeConcurrency(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("Parallel Get during Put and Delete with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, 3, opts...)
+			defer btree.Close()
+			for i := 0; i < 100; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+
+			var wg sync.WaitGroup
+			for writer := 0; writer < 2; writer++ {
+				wg.Add(1)
+				go func(writer int) {
+					defer wg.Done()
+					for i := 100 + writer; i < 300; i += 2 {
+						if err := btree.Put(&Sample{Int: i}); err != nil {
+							t.Errorf("Error should not be raised")
+						}
+						if err := btree.Delete(KeyType(i)); err != nil {
+							t.Errorf("Error should not be raised")
+						}
+					}
+				}(writer)
+			}
+			for reader := 0; reader < 4; reader++ {
+				wg.Add(1)
+				go func(reader int) {
+					defer wg.Done()
+					for round := 0; round < 5; round++ {
+						for i := 0; i < 100; i++ {
+							if item, err := btree.Get(KeyType(i)); err != nil || item.Int != i {
+								t.Errorf("Item should be found while other items are modified")
+							}
+						}
+					}
+				}(reader)
+			}
+			wg.Wait()
+			checkTree(t, btree)
+		})
+	}
+}
+
+func TestBTreeVariableLength(t *testing.T) {
+	t.Run("Put -> Get strings of various length", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, PAGE_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		for i := 0; i < 500; i++ {
+			if err = btree.Put(&Sample{Int: i, String: strings.Repeat("a", i%DEFAULT_STRING_MAX_LENGTH), String16: "b"}); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		checkTree(t, btree)
+		for i := 0; i < 500; i++ {
+			item, err := btree.Get(KeyType(i))
+			if err != nil || item.String != strings.Repeat("a", i%DEFAULT_STRING_MAX_LENGTH) || item.String16 != "b" {
+				t.Errorf("item.String should be restored for key %d", i)
+			}
+		}
+
+		rootNode, _ := btree.readNodeFromDisk(btree.getRootOffset())
+		if rootNode.isLeaf() || len(rootNode.elements) < 2 {
+			t.Errorf("Root node should have multiple children")
+		}
+	})
+	t.Run("Update strings to longer ones", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, PAGE_DEGREE)
+		defer btree.Close()
+
+		for _, length := range []int{0, 250} {
+			for i := 0; i < 200; i++ {
+				if err := btree.Put(&Sample{Int: i, String: strings.Repeat("a", length)}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+		}
+		if len(checkTree(t, btree)) != 200 {
+			t.Errorf("Tree should have 200 keys")
+		}
+		for i := 0; i < 200; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || item.String != strings.Repeat("a", 250) {
+				t.Errorf("item.String should be updated for key %d", i)
+			}
+		}
+	})
+	t.Run("Put -> Delete strings of different length in random order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+		random := rand.New(rand.NewSource(7))
+
+		btree, _ := New[Sample](path, PAGE_DEGREE, WithPageSize(2048))
+		defer btree.Close()
+
+		expected := map[int]int{}
+		for i := 0; i < 3000; i++ {
+			key := random.Intn(300)
+			if _, ok := expected[key]; ok && random.Intn(2) == 0 {
+				if err := btree.Delete(KeyType(key)); err != nil {
+					t.Fatalf("Error should not be raised")
+				}
+				delete(expected, key)
+				continue
+			}
+			length := random.Intn(2) * 250
+			if err := btree.Put(&Sample{Int: key, String: strings.Repeat("a", length)}); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			expected[key] = length
+		}
+		if len(checkTree(t, btree)) != len(expected) {
+			t.Errorf("Tree should have %d keys", len(expected))
+		}
+		for key, length := range expected {
+			if item, err := btree.Get(KeyType(key)); err != nil || len(item.String) != length {
+				t.Errorf("item.String of key %d should have %d bytes", key, length)
+			}
+		}
+	})
+	t.Run("Put string longer than maxLength", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, PAGE_DEGREE)
+		defer btree.Close()
+
+		if err := btree.Put(&Sample{Int: 1, String16: strings.Repeat("a", 17)}); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+	t.Run("Put wide items without page size", func(t *testing.T) {
+		for _, degree := range []int{DEFAULT_DEGREE, PAGE_DEGREE} {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, err := New[Wide](path, degree)
+			if err != nil {
+				t.Fatalf("Error should not be raised: %v", err)
+			}
+			if btree.pageSize <= DEFAULT_PAGE_SIZE {
+				t.Errorf("Page size should be larger than %d: %d", DEFAULT_PAGE_SIZE, btree.pageSize)
+			}
+			text := strings.Repeat("a", DEFAULT_STRING_MAX_LENGTH)
+			for i := 0; i < 100; i++ {
+				if err := btree.Put(&Wide{ID: i, Text1: text, Text2: text, Text3: text, Text4: text}); err != nil {
+					t.Fatalf("Error should not be raised: %v", err)
+				}
+			}
+			pageSize := btree.pageSize
+			btree.Close()
+
+			btree, err = New[Wide](path, degree)
+			if err != nil {
+				t.Fatalf("Error should not be raised: %v", err)
+			}
+			if btree.pageSize != pageSize {
+				t.Errorf("Page size should be read from the file: %d", btree.pageSize)
+			}
+			for i := 0; i < 100; i++ {
+				if item, err := btree.Get(int64(i)); err != nil || item == nil || item.Text4 != text {
+					t.Errorf("Item %d should be found: %v", i, err)
+				}
+			}
+			btree.Close()
+		}
+	})
+	t.Run("New with invalid degree", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		if _, err := New[Sample](path, 1); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := New[Sample](path, -1); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := New[Sample](path, 100); err == nil {
+			t.Errorf("Error should be raised when nodes do not fit in a page")
+		}
+		if _, err := New[Wide](path, PAGE_DEGREE, WithPageSize(DEFAULT_PAGE_SIZE)); err == nil {
+			t.Errorf("Error should be raised when items do not fit in a quarter of page")
+		}
+	})
+}
+
+type Wide struct {
+	ID    int
+	Text1 string
+	Text2 string
+	Text3 string
+	Text4 string
+}
+
+func (item Wide) GetKey() int64 {
+	return int64(item.ID)
+}
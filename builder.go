@@ -0,0 +1,186 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// builder writes a tree bottom-up from elements given in ascending order of keys.
+// Each level holds back enough elements to avoid under populated nodes at its end,
+// so that every node is written exactly once.
+type builder[T Item] struct {
+	btree      *BTree[T]
+	fillFactor float64
+	levels     []*Node[T]
+	lastLeaf   *Node[T]
+	isEmpty    bool
+	lastKey    KeyType
+}
+
+func newBuilder[T Item](btree *BTree[T], fillFactor float64) (*builder[T], error) {
+	if fillFactor <= 0 || fillFactor > 1 {
+		return nil, errors.New("Parameter 'fillFactor' should be greater than 0 and less than or equal to 1")
+	}
+	builder := new(builder[T])
+	builder.btree = btree
+	builder.fillFactor = fillFactor
+	builder.levels = []*Node[T]{newNode[T](0)}
+	builder.isEmpty = true
+	return builder, nil
+}
+
+func (builder *builder[T]) add(element *Element[T]) error {
+	if !builder.isEmpty && element.getKey() <= builder.lastKey {
+		return errors.New(fmt.Sprintf("Item with key %d is not given in ascending order", element.getKey()))
+	}
+	builder.isEmpty = false
+	builder.lastKey = element.getKey()
+	return builder.addElement(0, element)
+}
+
+// finish writes remaining nodes of every level and places the top node at the root offset.
+func (builder *builder[T]) finish() error {
+	for level := 0; level < len(builder.levels); level++ {
+		for builder.btree.isOverPopulated(builder.levels[level]) {
+			// Split remaining elements into two similar nodes if possible
+			node := builder.levels[level]
+			length := builder.btree.splitIndex(node)
+			left, right := builder.divide(node, length)
+			if builder.btree.isOverPopulated(left) || builder.btree.isOverPopulated(right) {
+				length = builder.fillLength(node)
+			}
+			if err := builder.emit(level, length); err != nil {
+				return err
+			}
+		}
+
+		node := builder.levels[level]
+		if level == len(builder.levels)-1 {
+			if builder.lastLeaf != nil {
+				if err := builder.btree.writeNodeToDisk(builder.lastLeaf); err != nil {
+					return err
+				}
+			}
+			node.offset = builder.btree.getRootOffset()
+			return builder.btree.writeNodeToDisk(node)
+		}
+		offset, err := builder.writeNode(node)
+		if err != nil {
+			return err
+		}
+		builder.addChildOffset(level+1, offset)
+	}
+	return nil
+}
+
+func (builder *builder[T]) addElement(level int, element *Element[T]) error {
+	node := builder.levels[level]
+	node.elements = append(node.elements, element)
+
+	// Emit a node only when remaining elements still overflow a node, so that the last node of this level is filled
+	length := builder.fillLength(node)
+	if length >= len(node.elements) {
+		return nil
+	}
+	if _, rest := builder.divide(node, length); !builder.btree.isOverPopulated(rest) {
+		return nil
+	}
+	return builder.emit(level, length)
+}
+
+func (builder *builder[T]) addChildOffset(level int, offset OffsetType) {
+	if level == len(builder.levels) {
+		builder.levels = append(builder.levels, newNode[T](0))
+	}
+	builder.levels[level].childOffsets = append(builder.levels[level].childOffsets, offset)
+}
+
+// emit writes the first length elements of a level as a node and passes the next element to the upper level.
+// Leaf level of B+tree passes only the key of the next element.
+func (builder *builder[T]) emit(level int, length int) error {
+	node := builder.levels[level]
+	first, rest := builder.divide(node, length)
+	offset, err := builder.writeNode(first)
+	if err != nil {
+		return err
+	}
+	separator := node.elements[length]
+	if builder.btree.isBPlusTree() && node.isLeaf() {
+		separator = newKeyElement[T](separator.getKey())
+	}
+	builder.levels[level] = rest
+
+	builder.addChildOffset(level+1, offset)
+	return builder.addElement(level+1, separator)
+}
+
+// divide returns nodes before and after the element at index without modifying node.
+// Leaf node of B+tree keeps the element at index in the latter node.
+func (builder *builder[T]) divide(node *Node[T], index int) (*Node[T], *Node[T]) {
+	first := newNode[T](0)
+	rest := newNode[T](0)
+	first.elements = node.elements[:index]
+	if builder.btree.isBPlusTree() && node.isLeaf() {
+		rest.elements = append([]*Element[T]{}, node.elements[index:]...)
+	} else {
+		rest.elements = append([]*Element[T]{}, node.elements[index+1:]...)
+	}
+	if len(node.childOffsets) > 0 {
+		first.childOffsets = node.childOffsets[:index+1]
+		rest.childOffsets = append([]OffsetType{}, node.childOffsets[index+1:]...)
+	}
+	return first, rest
+}
+
+// fillLength returns number of elements to put in a node to fill it up to the fill factor.
+func (builder *builder[T]) fillLength(node *Node[T]) int {
+	btree := builder.btree
+	if btree.degree != PAGE_DEGREE {
+		length := int(builder.fillFactor * float64(btree.maxElements()-1))
+		if length < btree.minElements() {
+			length = btree.minElements()
+		}
+		return length
+	}
+
+	fillSize := int(builder.fillFactor * float64(btree.nodeCapacity()))
+	if fillSize < btree.nodeCapacity()/2 {
+		fillSize = btree.nodeCapacity() / 2
+	}
+	size := 0
+	for i, element := range node.elements {
+		size += SLOT_SIZE_BYTE + element.sizeByte()
+		if len(node.childOffsets) > 0 {
+			size += OFFSET_SIZE_BYTE
+		}
+		if size > fillSize {
+			if i == 0 {
+				return 1
+			}
+			return i
+		}
+	}
+	return len(node.elements)
+}
+
+func (builder *builder[T]) writeNode(node *Node[T]) (OffsetType, error) {
+	offset, err := builder.btree.allocate()
+	if err != nil {
+		return 0, err
+	}
+	node.offset = offset
+	if !builder.btree.isBPlusTree() || !node.isLeaf() {
+		return offset, builder.btree.writeNodeToDisk(node)
+	}
+
+	// Leaf node of B+tree is written when the next leaf node is allocated, so that it can link to the next one
+	if builder.lastLeaf != nil {
+		builder.lastLeaf.nextOffset = offset
+		node.prevOffset = builder.lastLeaf.offset
+		if err = builder.btree.writeNodeToDisk(builder.lastLeaf); err != nil {
+			return 0, err
+		}
+	}
+	builder.lastLeaf = node
+	return offset, nil
+}
@@ -0,0 +1,76 @@
+package btree
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	for _, degree := range []int{2, 3, 5, PAGE_DEGREE} {
+		for _, fillFactor := range []float64{0.1, 0.5, 1} {
+			t.Run(fmt.Sprintf("Build with degree %d and fill factor %.1f", degree, fillFactor), func(t *testing.T) {
+				for _, count := range []int{0, 1, 2, 7, 30, 101, 500} {
+					path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+					btree, err := New[Sample](path, degree, withDirectWrites())
+					if err != nil {
+						t.Fatalf("Error should not be raised")
+					}
+					builder, err := newBuilder(btree, fillFactor)
+					if err != nil {
+						t.Fatalf("Error should not be raised")
+					}
+					for i := 0; i < count; i++ {
+						if err = builder.add(newElement(&Sample{Int: i * 2, String: strings.Repeat("x", i%DEFAULT_STRING_MAX_LENGTH)})); err != nil {
+							t.Errorf("Error should not be raised")
+						}
+					}
+					if err = builder.finish(); err != nil {
+						t.Errorf("Error should not be raised")
+					}
+
+					keys := checkTree(t, btree)
+					if len(keys) != count {
+						t.Errorf("Tree should have %d keys", count)
+					}
+					for i := 0; i < count; i++ {
+						if item, err := btree.Get(KeyType(i * 2)); err != nil || item.Int != i*2 {
+							t.Errorf("item.Int should be %d", i*2)
+						}
+					}
+					btree.Close()
+				}
+			})
+		}
+	}
+	t.Run("Add elements in wrong order", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		builder, _ := newBuilder(btree, 1)
+		builder.add(newElement(&Sample{Int: 2}))
+		if err := builder.add(newElement(&Sample{Int: 2})); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if err := builder.add(newElement(&Sample{Int: 1})); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+	t.Run("Invalid fill factor", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		if _, err := newBuilder(btree, 0); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := newBuilder(btree, 1.5); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Iterator returns items one by one, and nil after the last item.
+type Iterator[T Item] func() (*T, error)
+
+// BulkLoad creates a tree at path from items given by next in ascending order of keys.
+// Nodes are built bottom-up and packed to fillFactor, so that every page is written once
+// instead of traversing and splitting nodes for each item like Put.
+func BulkLoad[T Item](path string, degree int, next Iterator[T], fillFactor float64, opts ...Option) (*BTree[T], error) {
+	if err := isNewDataFile(path); err != nil {
+		return nil, err
+	}
+
+	// The tree is built in a separate file so that a partially loaded tree is never opened
+	loadPath := path + ".load"
+	os.Remove(loadPath)
+	loaded, err := New[T](loadPath, degree, append(opts, withDirectWrites())...)
+	if err != nil {
+		return nil, err
+	}
+	if err = loaded.load(next, fillFactor); err == nil {
+		err = loaded.fp.Sync()
+	}
+	if closeErr := loaded.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(loadPath, path)
+	}
+	if err != nil {
+		os.Remove(loadPath)
+		return nil, err
+	}
+	return New[T](path, degree, opts...)
+}
+
+func (btree *BTree[T]) load(next Iterator[T], fillFactor float64) error {
+	builder, err := newBuilder(btree, fillFactor)
+	if err != nil {
+		return err
+	}
+	for {
+		item, err := next()
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			break
+		}
+		if err = isValidStringLength(item); err != nil {
+			return err
+		}
+		element := newElement(item)
+		if err = btree.spillOverflows(element); err != nil {
+			return err
+		}
+		if err = builder.add(element); err != nil {
+			return err
+		}
+	}
+	return builder.finish()
+}
+
+func isNewDataFile(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return errors.New(fmt.Sprintf("Data file already exists at %s", path))
+	}
+	return nil
+}
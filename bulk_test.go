@@ -0,0 +1,112 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newSliceIterator[T Item](items []*T) Iterator[T] {
+	return func() (*T, error) {
+		if len(items) == 0 {
+			return nil, nil
+		}
+		item := items[0]
+		items = items[1:]
+		return item, nil
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("BulkLoad -> Put -> Reopen with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			documents := []*Document{}
+			for i := 0; i < 300; i++ {
+				documents = append(documents, newDocument(i*2, i*10))
+			}
+			btree, err := BulkLoad[Document](path, 3, newSliceIterator(documents), 0.8, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			if btree.header.freeOffset != 0 {
+				t.Errorf("Free page should not be left")
+			}
+			if _, err = os.Stat(path + ".load"); err == nil {
+				t.Errorf("Temporary file should be removed")
+			}
+			for i := 0; i < 300; i++ {
+				if err = btree.Put(newDocument(i*2+1, 10)); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			btree.Close()
+
+			btree, err = New[Document](path, 3, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+			for i := 0; i < 300; i++ {
+				if item, err := btree.Get(KeyType(i * 2)); err != nil || !isSameDocument(item, newDocument(i*2, i*10)) {
+					t.Errorf("Loaded document %d should be found", i*2)
+				}
+			}
+			if keys := checkTree(t, btree); len(keys) != 600 {
+				t.Errorf("Tree should have 600 keys")
+			}
+		})
+	}
+	t.Run("Fill factor", func(t *testing.T) {
+		sizes := []int64{}
+		for _, fillFactor := range []float64{0.5, 1} {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			samples := []*Sample{}
+			for i := 0; i < 1000; i++ {
+				samples = append(samples, &Sample{Int: i})
+			}
+			btree, err := BulkLoad[Sample](path, PAGE_DEGREE, newSliceIterator(samples), fillFactor)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			checkTree(t, btree)
+			btree.Close()
+			file, _ := os.Stat(path)
+			sizes = append(sizes, file.Size())
+		}
+		if sizes[1] >= sizes[0] {
+			t.Errorf("Tree packed to higher fill factor should be smaller")
+		}
+	})
+	t.Run("Invalid input", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		unsorted := []*Sample{{Int: 2}, {Int: 1}}
+		if _, err := BulkLoad[Sample](path, DEFAULT_DEGREE, newSliceIterator(unsorted), 1); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		failure := errors.New("failure")
+		failing := func() (*Sample, error) { return nil, failure }
+		if _, err := BulkLoad[Sample](path, DEFAULT_DEGREE, failing, 1); !errors.Is(err, failure) {
+			t.Errorf("Error of iterator should be raised")
+		}
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("Data file should not be created")
+		}
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		btree.Close()
+		if _, err := BulkLoad[Sample](path, DEFAULT_DEGREE, newSliceIterator([]*Sample{}), 1); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package btree
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+)
+
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendChecksum appends CRC32C of buff as a trailer.
+func appendChecksum(buff []byte) []byte {
+	checksum := make([]byte, CHECKSUM_SIZE_BYTE)
+	binary.BigEndian.PutUint32(checksum, crc32.Checksum(buff, checksumTable))
+	return append(buff, checksum...)
+}
+
+// verifyChecksum reports whether the trailer of buff matches CRC32C of the rest.
+func verifyChecksum(buff []byte) bool {
+	if len(buff) < CHECKSUM_SIZE_BYTE {
+		return false
+	}
+	body := buff[:len(buff)-CHECKSUM_SIZE_BYTE]
+	return binary.BigEndian.Uint32(buff[len(body):]) == crc32.Checksum(body, checksumTable)
+}
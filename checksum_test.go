@@ -0,0 +1,28 @@
+package btree
+
+import "testing"
+
+func TestChecksum(t *testing.T) {
+	t.Run("Test appendChecksum and verifyChecksum", func(t *testing.T) {
+		buff := appendChecksum([]byte("hello, world"))
+		if len(buff) != len("hello, world")+CHECKSUM_SIZE_BYTE {
+			t.Errorf("Checksum should be appended")
+		}
+		if !verifyChecksum(buff) {
+			t.Errorf("Checksum should be valid")
+		}
+
+		buff[0] ^= 1
+		if verifyChecksum(buff) {
+			t.Errorf("Checksum should be invalid")
+		}
+	})
+	t.Run("Test verifyChecksum with zeros", func(t *testing.T) {
+		if verifyChecksum(make([]byte, 64)) {
+			t.Errorf("Checksum should be invalid")
+		}
+		if verifyChecksum(make([]byte, 2)) {
+			t.Errorf("Checksum should be invalid")
+		}
+	})
+}
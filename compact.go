@@ -0,0 +1,195 @@
+package btree
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/exp/slices"
+)
+
+// Compact rebuilds the tree into a fresh file which contains only live elements packed
+// to fillFactor, and atomically replaces the data file with it.
+func (btree *BTree[T]) Compact(fillFactor float64) error {
+	if btree.isReadOnly {
+		return &ReadOnlyError{Operation: "Compact"}
+	}
+	btree.writeLock.Lock()
+	defer btree.writeLock.Unlock()
+	if !btree.isOpen {
+		return errors.New("Tree is closed")
+	}
+	// Operations kept in the WAL refer to pages of the file which is replaced
+	btree.lock.Lock()
+	err := btree.checkpoint()
+	btree.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	compactPath := btree.path + ".compact"
+	os.Remove(compactPath)
+	compacted, err := New[T](compactPath, btree.degree, append(btree.fileOptions(), withDirectWrites())...)
+	if err != nil {
+		return err
+	}
+	if err = btree.compactInto(compacted, fillFactor); err == nil {
+		err = compacted.fp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(compactPath, btree.path)
+	}
+	if err != nil {
+		compacted.Close()
+		os.Remove(compactPath)
+		return err
+	}
+
+	btree.lock.Lock()
+	defer btree.lock.Unlock()
+	// Snapshots keep reading the replaced file, which is no longer modified
+	btree.snapshots = map[*Snapshot[T]]bool{}
+	btree.fp.Close()
+	btree.fp = compacted.fp
+	btree.header = compacted.header
+	btree.endOffset = compacted.endOffset
+	btree.committedEndOffset = compacted.endOffset
+	btree.freePages = compacted.freePages
+	btree.heldFrees = nil
+	btree.syncedTxid = compacted.header.txid
+	// Cursors find their keys again in the new file
+	btree.commitCount += 1
+	return nil
+}
+
+// IncrementalVacuum shrinks the data file by up to maxPages pages and returns how many were reclaimed.
+// Nodes at the end of file are moved into free pages one at a time, so it can be called
+// repeatedly between other operations instead of rebuilding the whole tree like Compact.
+func (btree *BTree[T]) IncrementalVacuum(maxPages int) (int, error) {
+	if btree.isReadOnly {
+		return 0, &ReadOnlyError{Operation: "IncrementalVacuum"}
+	}
+	btree.writeLock.Lock()
+	defer btree.writeLock.Unlock()
+	if !btree.isOpen {
+		return 0, errors.New("Tree is closed")
+	}
+	if btree.isCopyOnWrite() {
+		return btree.incrementalVacuumCopyOnWrite(maxPages)
+	}
+
+	count := 0
+	err := btree.operate(func(tree *BTree[T]) error {
+		for count < maxPages {
+			isVacuumed, err := tree.vacuumLastPage()
+			if err != nil || !isVacuumed {
+				return err
+			}
+			count += 1
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (btree *BTree[T]) compactInto(compacted *BTree[T], fillFactor float64) error {
+	builder, err := newBuilder(compacted, fillFactor)
+	if err != nil {
+		return err
+	}
+	err = btree.walk(btree.getRootOffset(), func(element *Element[T]) error {
+		if element.isClosed {
+			return nil
+		}
+		// Copy values in overflow pages to the new file
+		if err := btree.loadOverflows(element); err != nil {
+			return err
+		}
+		element.overflows = nil
+		if err := compacted.spillOverflows(element); err != nil {
+			return err
+		}
+		return builder.add(element)
+	})
+	if err != nil {
+		return err
+	}
+	return builder.finish()
+}
+
+// vacuumLastPage truncates the last page of the data file after unlinking it from the free list
+// or moving the node or overflow data on it into a free page.
+func (btree *BTree[T]) vacuumLastPage() (bool, error) {
+	if btree.header.freeOffset == 0 {
+		return false, nil
+	}
+	lastOffset := btree.endOffset - OffsetType(btree.pageSize)
+
+	pageType := make([]byte, 1)
+	if err := btree.readAt(pageType, lastOffset); err != nil {
+		return false, &CorruptedError{Offset: lastOffset}
+	}
+	var err error
+	switch pageType[0] {
+	case PAGE_TYPE_FREE:
+		err = btree.unlinkFree(lastOffset)
+	case PAGE_TYPE_NODE:
+		err = btree.moveNode(lastOffset)
+	case PAGE_TYPE_OVERFLOW:
+		err = btree.moveOverflowPage(lastOffset)
+	default:
+		err = &CorruptedError{Offset: lastOffset}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The data file is truncated when the operation is committed
+	btree.endOffset = lastOffset
+	return true, nil
+}
+
+// moveNode copies the node at offset into a free page and points its parent to the new page.
+func (btree *BTree[T]) moveNode(offset OffsetType) error {
+	node, err := btree.readNodeFromDisk(offset)
+	if err != nil {
+		return err
+	}
+	newOffset, err := btree.allocate()
+	if err != nil {
+		return err
+	}
+
+	if offset == btree.getRootOffset() {
+		node.offset = newOffset
+		if err = btree.writeNodeToDisk(node); err != nil {
+			return err
+		}
+		return btree.writeRootOffsetToDisk(newOffset)
+	}
+
+	// Traversal by the first key passes through the node since internal nodes of B+tree also hold it
+	_, traversedNodes, traversedIndices, err := btree.traverse(node.elements[0].getKey())
+	if err != nil {
+		return err
+	}
+	depth := slices.IndexFunc(traversedNodes, func(traversedNode *Node[T]) bool {
+		return traversedNode.offset == offset
+	})
+	if depth < 1 {
+		return &CorruptedError{Offset: offset}
+	}
+	parentNode := traversedNodes[depth-1]
+	parentNode.childOffsets[traversedIndices[depth-1]] = newOffset
+
+	node.offset = newOffset
+	if err = btree.writeNodesToDisk(node, parentNode); err != nil {
+		return err
+	}
+	if err = btree.linkPrevLeaf(node); err != nil {
+		return err
+	}
+	return btree.linkNextLeaf(node)
+}
@@ -0,0 +1,122 @@
+package btree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	t.Run("Put -> Delete -> Compact -> Get", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 0; i < 500; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		for i := 0; i < 500; i++ {
+			if i%5 != 0 {
+				btree.Delete(KeyType(i))
+			}
+		}
+		before, _ := os.Stat(path)
+
+		if err = btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		after, _ := os.Stat(path)
+		if after.Size() >= before.Size() {
+			t.Errorf("File size should be reduced")
+		}
+		if _, err = os.Stat(path + ".compact"); !os.IsNotExist(err) {
+			t.Errorf("Temporary file should be removed")
+		}
+
+		if len(checkTree(t, btree)) != 100 {
+			t.Errorf("Tree should have 100 keys")
+		}
+		btree.Put(&Sample{Int: 1})
+		btree.Close()
+
+		btree, err = New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+		for i := 0; i < 500; i++ {
+			item, err := btree.Get(KeyType(i))
+			if i%5 == 0 || i == 1 {
+				if err != nil || item.Int != i {
+					t.Errorf("item.Int should be %d", i)
+				}
+			} else if err == nil {
+				t.Errorf("Error should be raised")
+			}
+		}
+	})
+	t.Run("Compact with invalid fill factor", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		btree.Put(&Sample{Int: 1})
+		if err := btree.Compact(0); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if item, err := btree.Get(1); err != nil || item.Int != 1 {
+			t.Errorf("Tree should be usable after failed compaction")
+		}
+	})
+	t.Run("Put -> Delete -> IncrementalVacuum -> Get", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		for i := 0; i < 500; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		for i := 0; i < 400; i++ {
+			btree.Delete(KeyType(i))
+		}
+		before, _ := os.Stat(path)
+
+		total := 0
+		for {
+			count, err := btree.IncrementalVacuum(5)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			total += count
+			if count < 5 {
+				break
+			}
+			if item, err := btree.Get(450); err != nil || item.Int != 450 {
+				t.Errorf("Get should work between vacuum steps")
+			}
+		}
+		after, _ := os.Stat(path)
+		if total == 0 || after.Size() != before.Size()-int64(total*btree.pageSize) {
+			t.Errorf("File should be truncated by vacuumed pages")
+		}
+		if btree.header.freeOffset != 0 {
+			t.Errorf("Free list should be empty")
+		}
+
+		if len(checkTree(t, btree)) != 100 {
+			t.Errorf("Tree should have 100 keys")
+		}
+		for i := 400; i < 500; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || item.Int != i {
+				t.Errorf("item.Int should be %d", i)
+			}
+		}
+	})
+}
@@ -1,16 +1,59 @@
 package btree
 
-import "reflect"
+import (
+	"reflect"
+	"time"
+)
 
 type OffsetType = int64
 type KeyType = int64
 type LengthInNodeType = int64
 
 const DEFAULT_DATA_PATH = "btree.bin"
+const MAGIC = "ODBTREE\x00"
+const FORMAT_VERSION = 9
+const HEADER_SIZE_BYTE = 68
+const HEADER_PAGE_COUNT = 2
 const OFFSET_SIZE_BYTE = 8
+const KEY_SIZE_BYTE = 8
+const CHECKSUM_SIZE_BYTE = 4
 const LENGTH_IN_NODE_BYTE = 8
+const STRING_LENGTH_BYTE = 4
+const SLOT_SIZE_BYTE = 4
+const NODE_HEADER_SIZE_BYTE = 24
 const DEFAULT_DEGREE = 3
+const PAGE_DEGREE = 0
+const DEFAULT_PAGE_SIZE = 4096
+const MIN_PAGE_SIZE = 512
+const MAX_PAGE_SIZE = 65536
 const DEFAULT_STRING_MAX_LENGTH = 256
+const INLINE_MAX_LENGTH = 256
+const OVERFLOW_HEADER_SIZE_BYTE = 24
+const WAL_RECORD_HEADER_SIZE_BYTE = 13
+const WAL_PATH_SUFFIX = ".wal"
+const LOCK_RETRY_INTERVAL = 10 * time.Millisecond
+const DEFAULT_SYNC_INTERVAL = time.Second
+
+const (
+	LAYOUT_BTREE = iota
+	LAYOUT_BPLUS_TREE
+)
+
+const (
+	JOURNAL_WAL = iota
+	JOURNAL_COPY_ON_WRITE
+)
+
+const (
+	WAL_RECORD_PAGE = iota + 1
+	WAL_RECORD_COMMIT
+)
+
+const (
+	PAGE_TYPE_NODE = iota + 1
+	PAGE_TYPE_FREE
+	PAGE_TYPE_OVERFLOW
+)
 
 var AVAILABLE_TYPES = []reflect.Kind{
 	reflect.Int,
@@ -27,4 +70,12 @@ var AVAILABLE_TYPES = []reflect.Kind{
 	reflect.Float64,
 	reflect.Bool,
 	reflect.String,
+	reflect.Slice,
 }
+
+const (
+	SYNC_OPERATION = iota
+	SYNC_WRITE
+	SYNC_INTERVAL
+	SYNC_NEVER
+)
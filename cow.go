@@ -0,0 +1,322 @@
+package btree
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/exp/slices"
+)
+
+// In copy-on-write mode, pages of the last committed tree are never overwritten. Pages written by an operation
+// are relocated to free pages on commit together with every ancestor which refers to them, and the new tree
+// becomes visible when the header is written into the slot not used by the last commit.
+// Free pages are not persisted but found on open as pages unreachable from the root.
+
+// allocateCopyOnWrite returns the lowest free page so that the end of file is freed first.
+func (btree *BTree[T]) allocateCopyOnWrite() OffsetType {
+	offset := btree.endOffset
+	if len(btree.freePages) > 0 {
+		offset = btree.freePages[0]
+		btree.freePages = btree.freePages[1:]
+	} else {
+		btree.endOffset += OffsetType(btree.pageSize)
+	}
+	btree.allocatedPages[offset] = true
+	return offset
+}
+
+// heldPage is a page freed by the commit of txid, which is not reused until releaseFrees.
+type heldPage struct {
+	offset OffsetType
+	txid   uint64
+}
+
+// freeCopyOnWrite keeps the page until the operation is committed, since the last committed tree may refer to it.
+func (btree *BTree[T]) freeCopyOnWrite(offset OffsetType) {
+	btree.pendingFrees = append(btree.pendingFrees, offset)
+}
+
+func (btree *BTree[T]) commitCopyOnWrite() error {
+	if err := btree.relocatePages(); err != nil {
+		return err
+	}
+	if err := btree.writeDirtyPages(); err != nil {
+		return err
+	}
+	// The header of the last commit has been synced with the pages
+	btree.syncedTxid = btree.header.txid
+
+	btree.header.txid += 1
+	if _, err := btree.fp.WriteAt(btree.header.serialize(), btree.headerSlotOffset()); err != nil {
+		return err
+	}
+	if err := btree.syncFile(btree.fp); err != nil {
+		return err
+	}
+	if btree.syncPolicy != SYNC_INTERVAL {
+		btree.syncedTxid = btree.header.txid
+	}
+
+	for _, offset := range btree.pendingFrees {
+		btree.heldFrees = append(btree.heldFrees, heldPage{offset: offset, txid: btree.header.txid})
+	}
+	btree.releaseFrees()
+	btree.committedEndOffset = btree.endOffset
+	btree.operation = newOperation[T]()
+	// Pages beyond the end are unreachable from the new root, so they are freed on open if truncation fails
+	btree.fp.Truncate(btree.endOffset)
+	return nil
+}
+
+// releaseFrees reuses pages freed by a commit once its header is synced and no open snapshot is older than it,
+// since the header on disk or the snapshot may still refer to them until then.
+func (btree *BTree[T]) releaseFrees() {
+	txid := btree.syncedTxid
+	for snapshot := range btree.snapshots {
+		if snapshot.tree.header.txid < txid {
+			txid = snapshot.tree.header.txid
+		}
+	}
+	heldFrees := []heldPage{}
+	for _, page := range btree.heldFrees {
+		if page.txid <= txid {
+			btree.freePages = append(btree.freePages, page.offset)
+		} else {
+			heldFrees = append(heldFrees, page)
+		}
+	}
+	btree.heldFrees = heldFrees
+	slices.Sort(btree.freePages)
+}
+
+// relocatePages moves dirty pages of the last committed tree to newly allocated pages and rewrites pages
+// which refer to them, until no page of the last committed tree is modified.
+func (btree *BTree[T]) relocatePages() error {
+	// Pages freed by the operation are neither written nor referred
+	for _, offset := range btree.pendingFrees {
+		delete(btree.dirtyPages, offset)
+		delete(btree.cleanPages, offset)
+	}
+
+	relocated := map[OffsetType]OffsetType{}
+	queue := []OffsetType{}
+	for offset := range btree.dirtyPages {
+		if btree.isCommittedPage(offset) {
+			queue = append(queue, offset)
+		}
+	}
+	for len(queue) > 0 {
+		for _, offset := range queue {
+			relocated[offset] = btree.allocateCopyOnWrite()
+			btree.freeCopyOnWrite(offset)
+		}
+		queue = []OffsetType{}
+
+		// Parents of relocated pages have been read by the operation since every page is reached from the root
+		offsets := sortedOffsets(btree.dirtyPages)
+		for offset := range btree.cleanPages {
+			if _, ok := btree.dirtyPages[offset]; !ok {
+				offsets = append(offsets, offset)
+			}
+		}
+		for _, offset := range offsets {
+			buff, ok := btree.dirtyPages[offset]
+			if !ok {
+				buff = btree.cleanPages[offset]
+			}
+			patched, err := btree.patchReferences(offset, buff, relocated)
+			if err != nil {
+				return err
+			}
+			if patched == nil {
+				continue
+			}
+			btree.dirtyPages[offset] = patched
+			if _, ok := relocated[offset]; !ok && btree.isCommittedPage(offset) && !slices.Contains(queue, offset) {
+				queue = append(queue, offset)
+			}
+		}
+	}
+
+	for offset, newOffset := range relocated {
+		btree.dirtyPages[newOffset] = btree.dirtyPages[offset]
+		delete(btree.dirtyPages, offset)
+	}
+	if newOffset, ok := relocated[btree.header.rootOffset]; ok {
+		btree.header.rootOffset = newOffset
+	}
+	return nil
+}
+
+// patchReferences returns the page with offsets of relocated pages replaced, or nil if it has no such reference.
+func (btree *BTree[T]) patchReferences(offset OffsetType, buff []byte, relocated map[OffsetType]OffsetType) ([]byte, error) {
+	if !verifyChecksum(buff) {
+		return nil, &CorruptedError{Offset: offset}
+	}
+	isPatched := false
+	patch := func(offset *OffsetType) {
+		if newOffset, ok := relocated[*offset]; ok {
+			*offset = newOffset
+			isPatched = true
+		}
+	}
+
+	switch buff[0] {
+	case PAGE_TYPE_NODE:
+		node := newNode[T](offset)
+		node.deserialize(buff)
+		for i := range node.childOffsets {
+			patch(&node.childOffsets[i])
+		}
+		for _, element := range node.elements {
+			for _, overflow := range element.overflows {
+				patch(&overflow.offset)
+			}
+		}
+		if isPatched {
+			return appendChecksum(node.serialize(btree.pageSize)), nil
+		}
+	case PAGE_TYPE_OVERFLOW:
+		nextOffset := OffsetType(binary.BigEndian.Uint64(buff[1:9]))
+		patch(&nextOffset)
+		if isPatched {
+			patched := append([]byte{}, buff[:len(buff)-CHECKSUM_SIZE_BYTE]...)
+			binary.BigEndian.PutUint64(patched[1:9], uint64(nextOffset))
+			return appendChecksum(patched), nil
+		}
+	}
+	return nil, nil
+}
+
+// writeDirtyPages writes pages which are not referred by the last committed tree and syncs them before the header is written.
+func (btree *BTree[T]) writeDirtyPages() error {
+	for _, offset := range sortedOffsets(btree.dirtyPages) {
+		if err := btree.writePage(btree.dirtyPages[offset], offset); err != nil {
+			return err
+		}
+	}
+	return btree.syncBarrier(btree.fp)
+}
+
+// In copy-on-write mode, a live page at the end of file is copied into a free page by one operation
+// and truncated by the next one, since the last committed tree refers to it until the copy is committed.
+func (btree *BTree[T]) incrementalVacuumCopyOnWrite(maxPages int) (int, error) {
+	count := 0
+	for attempt := 0; count < maxPages && attempt < maxPages*2; attempt++ {
+		isVacuumed, isMoved := false, false
+		err := btree.operate(func(tree *BTree[T]) error {
+			var err error
+			isVacuumed, isMoved, err = tree.vacuumLastPageCopyOnWrite()
+			return err
+		})
+		if err != nil {
+			return count, err
+		}
+		if isVacuumed {
+			count += 1
+		} else if !isMoved {
+			break
+		}
+	}
+	return count, nil
+}
+
+// vacuumLastPageCopyOnWrite truncates the last page if it is free, otherwise moves the node or overflow data on it.
+func (btree *BTree[T]) vacuumLastPageCopyOnWrite() (bool, bool, error) {
+	if len(btree.freePages) == 0 {
+		return false, false, nil
+	}
+	lastOffset := btree.endOffset - OffsetType(btree.pageSize)
+	if btree.freePages[len(btree.freePages)-1] == lastOffset {
+		btree.freePages = btree.freePages[:len(btree.freePages)-1]
+		btree.endOffset = lastOffset
+		return true, false, nil
+	}
+
+	pageType := make([]byte, 1)
+	if err := btree.readAt(pageType, lastOffset); err != nil {
+		return false, false, &CorruptedError{Offset: lastOffset}
+	}
+	var err error
+	switch pageType[0] {
+	case PAGE_TYPE_NODE:
+		err = btree.moveNode(lastOffset)
+	case PAGE_TYPE_OVERFLOW:
+		err = btree.moveOverflowPage(lastOffset)
+	default:
+		err = &CorruptedError{Offset: lastOffset}
+	}
+	if err != nil {
+		return false, false, err
+	}
+	btree.freeCopyOnWrite(lastOffset)
+	return false, true, nil
+}
+
+// loadFreePages finds pages which are not reachable from the root, including pages written by an operation
+// which was not committed.
+func (btree *BTree[T]) loadFreePages() error {
+	isReachable := map[OffsetType]bool{}
+	var mark func(offset OffsetType) error
+	mark = func(offset OffsetType) error {
+		// A page referred twice means the tree is broken, and walking it again may never end
+		if isReachable[offset] {
+			return &CorruptedError{Offset: offset}
+		}
+		isReachable[offset] = true
+		node, err := btree.readNodeFromDisk(offset)
+		if err != nil {
+			return err
+		}
+		for _, element := range node.elements {
+			for _, overflow := range element.overflows {
+				for overflowOffset := overflow.offset; overflowOffset != 0; {
+					isReachable[overflowOffset] = true
+					page, err := btree.readOverflowPageFromDisk(overflowOffset)
+					if err != nil {
+						return err
+					}
+					overflowOffset = page.nextOffset
+				}
+			}
+		}
+		for _, childOffset := range node.childOffsets {
+			if err = mark(childOffset); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := mark(btree.getRootOffset()); err != nil {
+		return err
+	}
+
+	btree.freePages = []OffsetType{}
+	for offset := btree.firstPageOffset(); offset < btree.endOffset; offset += OffsetType(btree.pageSize) {
+		if !isReachable[offset] {
+			btree.freePages = append(btree.freePages, offset)
+		}
+	}
+	return nil
+}
+
+// isCommittedPage reports whether the last committed tree may refer to the page at offset.
+func (btree *BTree[T]) isCommittedPage(offset OffsetType) bool {
+	return offset >= btree.firstPageOffset() && offset < btree.committedEndOffset && !btree.allocatedPages[offset]
+}
+
+// headerSlotOffset returns the slot for the header of the current txid, which alternates on every commit.
+func (btree *BTree[T]) headerSlotOffset() OffsetType {
+	if btree.header.txid%2 == 0 {
+		return 0
+	}
+	return OffsetType(btree.pageSize)
+}
+
+// firstPageOffset returns the offset of the first page after the header slots.
+func (btree *BTree[T]) firstPageOffset() OffsetType {
+	return OffsetType(btree.pageSize * HEADER_PAGE_COUNT)
+}
+
+func (btree *BTree[T]) isCopyOnWrite() bool {
+	return btree.journal == JOURNAL_COPY_ON_WRITE
+}
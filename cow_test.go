@@ -0,0 +1,280 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readPages returns contents of all node and overflow pages reachable from the root.
+func readPages[T Item](t *testing.T, btree *BTree[T]) map[OffsetType][]byte {
+	pages := map[OffsetType][]byte{}
+	readPage := func(offset OffsetType) {
+		buff := make([]byte, btree.pageSize)
+		btree.fp.ReadAt(buff, offset)
+		pages[offset] = buff
+	}
+	var readNode func(offset OffsetType)
+	readNode = func(offset OffsetType) {
+		readPage(offset)
+		node, err := btree.readNodeFromDisk(offset)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for _, element := range node.elements {
+			for _, overflow := range element.overflows {
+				for overflowOffset := overflow.offset; overflowOffset != 0; {
+					readPage(overflowOffset)
+					page, _ := btree.readOverflowPageFromDisk(overflowOffset)
+					overflowOffset = page.nextOffset
+				}
+			}
+		}
+		for _, childOffset := range node.childOffsets {
+			readNode(childOffset)
+		}
+	}
+	readNode(btree.getRootOffset())
+	return pages
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	for _, degree := range []int{2, 3, PAGE_DEGREE} {
+		t.Run(fmt.Sprintf("Put -> Delete -> Reopen with degree %d", degree), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+			random := rand.New(rand.NewSource(int64(degree)))
+
+			btree, err := New[Sample](path, degree, WithCopyOnWrite())
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			fileSize := int64(0)
+			for round := 0; round < 3; round++ {
+				for _, key := range random.Perm(200) {
+					if err = btree.Put(&Sample{Int: key}); err != nil {
+						t.Errorf("Error should not be raised")
+					}
+				}
+				for _, key := range random.Perm(200)[:150] {
+					if err = btree.Delete(KeyType(key)); err != nil {
+						t.Errorf("Error should not be raised")
+					}
+				}
+				file, _ := os.Stat(path)
+				if round > 0 && file.Size() > fileSize*2 {
+					t.Errorf("Free pages should be reused")
+				}
+				fileSize = file.Size()
+			}
+			keys := checkTree(t, btree)
+			btree.Close()
+
+			btree, err = New[Sample](path, degree, WithCopyOnWrite())
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+			if fmt.Sprint(checkTree(t, btree)) != fmt.Sprint(keys) {
+				t.Errorf("Tree should be restored")
+			}
+			if _, err := os.Stat(path + WAL_PATH_SUFFIX); err == nil {
+				t.Errorf("WAL should not be created")
+			}
+		})
+	}
+	t.Run("Pages of the last committed tree are not overwritten", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		defer btree.Close()
+		for i := 0; i < 30; i++ {
+			btree.Put(newDocument(i, 1000))
+		}
+
+		operations := []func() error{
+			func() error { return btree.Put(newDocument(5, 8000)) },
+			func() error { return btree.Delete(10) },
+			func() error { return btree.Put(newDocument(100, 100)) },
+		}
+		for _, operation := range operations {
+			pages := readPages(t, btree)
+			rootOffset := btree.getRootOffset()
+			if err := operation(); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			if btree.getRootOffset() == rootOffset {
+				t.Errorf("Root should be moved")
+			}
+			for offset, page := range pages {
+				buff := make([]byte, btree.pageSize)
+				btree.fp.ReadAt(buff, offset)
+				if !bytes.Equal(buff, page) {
+					t.Errorf("Page at %d of the last committed tree should not be overwritten", offset)
+				}
+			}
+		}
+		if item, err := btree.Get(5); err != nil || !isSameDocument(item, newDocument(5, 8000)) {
+			t.Errorf("Updated item should be found")
+		}
+	})
+	t.Run("Crash before the header is written", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		for i := 0; i < 50; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		tree := btree.fork()
+		for i := 50; i < 100; i++ {
+			tree.put(&Sample{Int: i})
+		}
+		if err := tree.relocatePages(); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if err := tree.writeDirtyPages(); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		btree.close()
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+		if keys := checkTree(t, btree); len(keys) != 50 {
+			t.Errorf("Tree of the last commit should be used")
+		}
+		if len(btree.freePages) == 0 {
+			t.Errorf("Pages written by uncommitted operation should be free")
+		}
+		for i := 50; i < 100; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		if keys := checkTree(t, btree); len(keys) != 100 {
+			t.Errorf("Free pages should be reused")
+		}
+	})
+	t.Run("Torn header falls back to the previous commit", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		for i := 0; i < 10; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		slotOffset := btree.headerSlotOffset()
+		btree.Close()
+
+		fp, _ := os.OpenFile(path, os.O_RDWR, 0660)
+		fp.WriteAt([]byte{0xff, 0xff}, slotOffset+40)
+		fp.Close()
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+		if keys := checkTree(t, btree); len(keys) != 9 {
+			t.Errorf("Tree of the previous commit should be used")
+		}
+	})
+	t.Run("Header in the second slot is used when the first page is destroyed", func(t *testing.T) {
+		for _, pageSize := range []int{MIN_PAGE_SIZE, DEFAULT_PAGE_SIZE} {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, err := New[Other](path, 3, WithPageSize(pageSize), WithCopyOnWrite())
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			for i := 0; i < 100; i++ {
+				if err = btree.Put(&Other{ID: i}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			// Make the last commit write the first slot so that the second slot holds the previous one
+			expected := 99
+			if btree.headerSlotOffset() != 0 {
+				btree.Delete(KeyType(0))
+				expected = 100
+			}
+			btree.Close()
+
+			fp, _ := os.OpenFile(path, os.O_RDWR, 0660)
+			fp.WriteAt(bytes.Repeat([]byte{0xff}, pageSize), 0)
+			fp.Close()
+
+			btree, err = New[Other](path, 3, WithCopyOnWrite())
+			if err != nil {
+				t.Fatalf("Error should not be raised: %v", err)
+			}
+			if btree.pageSize != pageSize {
+				t.Errorf("Page size should be read from the second slot: %d", btree.pageSize)
+			}
+			if keys := checkTree(t, btree); len(keys) != expected {
+				t.Errorf("Tree of the previous commit should be used")
+			}
+			btree.Close()
+		}
+	})
+	t.Run("IncrementalVacuum and Compact", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		defer btree.Close()
+		for i := 0; i < 100; i++ {
+			btree.Put(newDocument(i, 2000))
+		}
+		for i := 0; i < 80; i++ {
+			btree.Delete(KeyType(i))
+		}
+
+		before, _ := os.Stat(path)
+		for {
+			count, err := btree.IncrementalVacuum(8)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			if count == 0 {
+				break
+			}
+		}
+		after, _ := os.Stat(path)
+		if after.Size() >= before.Size() {
+			t.Errorf("File should be truncated")
+		}
+		checkTree(t, btree)
+
+		if err := btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		btree.Put(newDocument(0, 2000))
+		for i := 80; i < 100; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || !isSameDocument(item, newDocument(i, 2000)) {
+				t.Errorf("Document %d should be kept", i)
+			}
+		}
+		if keys := checkTree(t, btree); len(keys) != 21 {
+			t.Errorf("Tree should be usable after Compact")
+		}
+	})
+	t.Run("Invalid options", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		if _, err := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite(), WithBPlusTree()); err == nil {
+			t.Errorf("Error should be raised")
+		}
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE, WithCopyOnWrite())
+		btree.Close()
+
+		var incompatibleFileError *IncompatibleFileError
+		_, err := New[Sample](path, DEFAULT_DEGREE)
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "journal mode" {
+			t.Errorf("IncompatibleFileError for journal mode should be raised")
+		}
+	})
+}
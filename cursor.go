@@ -0,0 +1,273 @@
+package btree
+
+import "errors"
+
+// Cursor moves over items of a tree in both directions. It keeps nodes traversed from the root to the current element
+// like traverse, where the index of the last node points to the element and indices of others point to the child.
+// When the tree is committed between calls, the cursor finds its key again before it moves.
+type Cursor[T Item] struct {
+	btree       *BTree[T]
+	nodes       []*Node[T]
+	indices     []int
+	commitCount int
+}
+
+// Cursor returns a cursor which is not positioned until First, Last or Seek is called.
+func (btree *BTree[T]) Cursor() *Cursor[T] {
+	cursor := new(Cursor[T])
+	cursor.btree = btree
+	return cursor
+}
+
+// First moves to the item with the smallest key and reports whether it is found.
+func (cursor *Cursor[T]) First() (bool, error) {
+	return cursor.move(func(tree *BTree[T]) error {
+		cursor.reset()
+		if err := cursor.pushLeftmost(tree.getRootOffset()); err != nil {
+			return err
+		}
+		return cursor.settle(true)
+	})
+}
+
+// Last moves to the item with the largest key and reports whether it is found.
+func (cursor *Cursor[T]) Last() (bool, error) {
+	return cursor.move(func(tree *BTree[T]) error {
+		cursor.reset()
+		if err := cursor.pushRightmost(tree.getRootOffset()); err != nil {
+			return err
+		}
+		return cursor.settle(false)
+	})
+}
+
+// Seek moves to the item with the smallest key greater than or equal to key and reports whether it is found.
+func (cursor *Cursor[T]) Seek(key KeyType) (bool, error) {
+	return cursor.move(func(tree *BTree[T]) error {
+		return cursor.seek(key)
+	})
+}
+
+// Next moves to the next item and reports whether it is found. Cursor which is not positioned does not move.
+func (cursor *Cursor[T]) Next() (bool, error) {
+	return cursor.move(func(tree *BTree[T]) error {
+		if !cursor.isValid() {
+			return nil
+		}
+		if cursor.commitCount != tree.commitCount {
+			key := cursor.Key()
+			if err := cursor.seek(key); err != nil || !cursor.isValid() || cursor.Key() != key {
+				return err
+			}
+		}
+		if err := cursor.stepNext(); err != nil {
+			return err
+		}
+		return cursor.settle(true)
+	})
+}
+
+// Prev moves to the previous item and reports whether it is found. Cursor which is not positioned does not move.
+func (cursor *Cursor[T]) Prev() (bool, error) {
+	return cursor.move(func(tree *BTree[T]) error {
+		if !cursor.isValid() {
+			return nil
+		}
+		if cursor.commitCount != tree.commitCount {
+			if err := cursor.seek(cursor.Key()); err != nil {
+				return err
+			}
+			// Every item is less than the key when nothing is found
+			if !cursor.isValid() {
+				if err := cursor.pushRightmost(tree.getRootOffset()); err != nil {
+					return err
+				}
+				return cursor.settle(false)
+			}
+		}
+		if err := cursor.stepPrev(); err != nil {
+			return err
+		}
+		return cursor.settle(false)
+	})
+}
+
+// Key returns the key of the current item. It should be called only while the cursor is positioned.
+func (cursor *Cursor[T]) Key() KeyType {
+	return cursor.element().getKey()
+}
+
+// Item returns the current item as it was when the cursor moved to it, or nil if the cursor is not positioned.
+func (cursor *Cursor[T]) Item() *T {
+	if !cursor.isValid() {
+		return nil
+	}
+	return cursor.element().item
+}
+
+// move runs fn while the tree is locked for reading. Cursor is not positioned when fn fails.
+func (cursor *Cursor[T]) move(fn func(tree *BTree[T]) error) (bool, error) {
+	btree := cursor.btree
+	btree.lock.RLock()
+	defer btree.lock.RUnlock()
+	if !btree.isOpen {
+		cursor.reset()
+		return false, errors.New("Tree is closed")
+	}
+	if err := fn(btree); err != nil {
+		cursor.reset()
+		return false, err
+	}
+	cursor.commitCount = btree.commitCount
+	return cursor.isValid(), nil
+}
+
+func (cursor *Cursor[T]) seek(key KeyType) error {
+	isFound, traversedNodes, traversedIndices, err := cursor.btree.traverse(key)
+	if err != nil {
+		return err
+	}
+	cursor.nodes = traversedNodes
+	cursor.indices = traversedIndices
+	if !isFound {
+		// Elements of the leaf are less than key when index is at its end, so the next element is in an ancestor
+		last := len(cursor.nodes) - 1
+		if length := len(cursor.nodes[last].elements); length == 0 {
+			cursor.reset()
+			return nil
+		} else if cursor.indices[last] == length {
+			cursor.indices[last] = length - 1
+			if err = cursor.stepNext(); err != nil {
+				return err
+			}
+		}
+	}
+	return cursor.settle(true)
+}
+
+// settle skips key only elements of B+tree and closed elements, and loads overflows of the element it stops at.
+func (cursor *Cursor[T]) settle(isForward bool) error {
+	for cursor.isValid() {
+		element := cursor.element()
+		if element.item != nil && !element.isClosed {
+			return cursor.btree.loadOverflows(element)
+		}
+		var err error
+		if isForward {
+			err = cursor.stepNext()
+		} else {
+			err = cursor.stepPrev()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stepNext moves to the next element in order, which is the leftmost element under the next child
+// of an internal node, or the element of the nearest ancestor whose child holds the current element.
+func (cursor *Cursor[T]) stepNext() error {
+	last := len(cursor.nodes) - 1
+	node, index := cursor.nodes[last], cursor.indices[last]
+	if !node.isLeaf() {
+		cursor.indices[last] = index + 1
+		return cursor.pushLeftmost(node.childOffsets[index+1])
+	}
+	if index+1 < len(node.elements) {
+		cursor.indices[last] = index + 1
+		return nil
+	}
+	for {
+		cursor.pop()
+		if !cursor.isValid() {
+			return nil
+		}
+		last = len(cursor.nodes) - 1
+		if cursor.indices[last] < len(cursor.nodes[last].elements) {
+			return nil
+		}
+	}
+}
+
+// stepPrev moves to the previous element in order, which is the rightmost element under the child
+// before the current element of an internal node, or the element before the child in the nearest ancestor.
+func (cursor *Cursor[T]) stepPrev() error {
+	last := len(cursor.nodes) - 1
+	node, index := cursor.nodes[last], cursor.indices[last]
+	if !node.isLeaf() {
+		return cursor.pushRightmost(node.childOffsets[index])
+	}
+	if index > 0 {
+		cursor.indices[last] = index - 1
+		return nil
+	}
+	for {
+		cursor.pop()
+		if !cursor.isValid() {
+			return nil
+		}
+		last = len(cursor.nodes) - 1
+		if cursor.indices[last] > 0 {
+			cursor.indices[last] -= 1
+			return nil
+		}
+	}
+}
+
+func (cursor *Cursor[T]) pushLeftmost(offset OffsetType) error {
+	for {
+		node, err := cursor.btree.readNodeFromDisk(offset)
+		if err != nil {
+			return err
+		}
+		cursor.nodes = append(cursor.nodes, node)
+		cursor.indices = append(cursor.indices, 0)
+		if node.isLeaf() {
+			// Only the root of an empty tree has no element
+			if len(node.elements) == 0 {
+				cursor.reset()
+			}
+			return nil
+		}
+		offset = node.childOffsets[0]
+	}
+}
+
+func (cursor *Cursor[T]) pushRightmost(offset OffsetType) error {
+	for {
+		node, err := cursor.btree.readNodeFromDisk(offset)
+		if err != nil {
+			return err
+		}
+		cursor.nodes = append(cursor.nodes, node)
+		if node.isLeaf() {
+			cursor.indices = append(cursor.indices, len(node.elements)-1)
+			if len(node.elements) == 0 {
+				cursor.reset()
+			}
+			return nil
+		}
+		cursor.indices = append(cursor.indices, len(node.elements))
+		offset = node.childOffsets[len(node.childOffsets)-1]
+	}
+}
+
+func (cursor *Cursor[T]) pop() {
+	cursor.nodes = cursor.nodes[:len(cursor.nodes)-1]
+	cursor.indices = cursor.indices[:len(cursor.indices)-1]
+}
+
+func (cursor *Cursor[T]) reset() {
+	cursor.nodes = nil
+	cursor.indices = nil
+}
+
+func (cursor *Cursor[T]) isValid() bool {
+	return len(cursor.nodes) > 0
+}
+
+func (cursor *Cursor[T]) element() *Element[T] {
+	last := len(cursor.nodes) - 1
+	return cursor.nodes[last].elements[cursor.indices[last]]
+}
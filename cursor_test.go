@@ -0,0 +1,172 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/exp/slices"
+)
+
+func TestCursor(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		for _, degree := range []int{2, 3, PAGE_DEGREE} {
+			t.Run(fmt.Sprintf("Move cursor with %s and degree %d", name, degree), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+				random := rand.New(rand.NewSource(int64(degree)))
+
+				btree, _ := New[Sample](path, degree, opts...)
+				defer btree.Close()
+				for _, key := range random.Perm(300) {
+					btree.Put(&Sample{Int: key * 2})
+				}
+				for _, key := range random.Perm(300)[:100] {
+					btree.Delete(KeyType(key * 2))
+				}
+				expected := []int{}
+				btree.Ascend(func(item *Sample) bool {
+					expected = append(expected, item.Int)
+					return true
+				})
+
+				cursor := btree.Cursor()
+				keys := []int{}
+				for isFound, err := cursor.First(); isFound; isFound, err = cursor.Next() {
+					if err != nil {
+						t.Fatalf("Error should not be raised")
+					}
+					keys = append(keys, cursor.Item().Int)
+				}
+				if fmt.Sprint(keys) != fmt.Sprint(expected) {
+					t.Errorf("Every item should be visited in ascending order")
+				}
+				keys = []int{}
+				for isFound, err := cursor.Last(); isFound; isFound, err = cursor.Prev() {
+					if err != nil {
+						t.Fatalf("Error should not be raised")
+					}
+					keys = append([]int{int(cursor.Key())}, keys...)
+				}
+				if fmt.Sprint(keys) != fmt.Sprint(expected) {
+					t.Errorf("Every item should be visited in descending order")
+				}
+
+				// Random moves are compared with the position in the sorted keys
+				position := -1
+				for i := 0; i < 500; i++ {
+					var isFound bool
+					var err error
+					switch random.Intn(4) {
+					case 0:
+						key := random.Intn(620) - 10
+						isFound, err = cursor.Seek(KeyType(key))
+						position = sortSearch(expected, key)
+					case 1, 2:
+						if position < 0 || position >= len(expected) {
+							continue
+						}
+						isFound, err = cursor.Next()
+						position += 1
+					case 3:
+						if position < 0 || position >= len(expected) {
+							continue
+						}
+						isFound, err = cursor.Prev()
+						position -= 1
+					}
+					if err != nil {
+						t.Fatalf("Error should not be raised")
+					}
+					if isFound != (position >= 0 && position < len(expected)) {
+						t.Fatalf("Cursor should be positioned only within the tree")
+					}
+					if isFound && int(cursor.Key()) != expected[position] {
+						t.Fatalf("Cursor should be at %d but at %d", expected[position], cursor.Key())
+					}
+				}
+			})
+		}
+	}
+	t.Run("Cursor stays valid across commits", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 100; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		cursor := btree.Cursor()
+		cursor.Seek(50)
+		for i := 0; i < 50; i++ {
+			btree.Delete(KeyType(i * 2))
+		}
+		if isFound, err := cursor.Next(); err != nil || !isFound || cursor.Key() != 51 {
+			t.Errorf("Cursor should move from its key after the tree is modified")
+		}
+		btree.Delete(51)
+		btree.Delete(49)
+		if isFound, err := cursor.Prev(); err != nil || !isFound || cursor.Key() != 47 {
+			t.Errorf("Cursor should move from its deleted key")
+		}
+		if err := btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if isFound, err := cursor.Next(); err != nil || !isFound || cursor.Key() != 53 {
+			t.Errorf("Cursor should move after Compact")
+		}
+		if isFound, _ := cursor.Seek(99); !isFound || cursor.Item().Int != 99 {
+			t.Errorf("Item should be found")
+		}
+		btree.Delete(99)
+		if isFound, err := cursor.Prev(); err != nil || !isFound || cursor.Key() != 97 {
+			t.Errorf("Cursor should move from the deleted last key")
+		}
+	})
+	t.Run("Items in overflow pages", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		for i := 0; i < 20; i++ {
+			btree.Put(newDocument(i, i*100))
+		}
+		cursor := btree.Cursor()
+		for isFound, _ := cursor.Last(); isFound; isFound, _ = cursor.Prev() {
+			if item := cursor.Item(); !isSameDocument(item, newDocument(item.ID, item.ID*100)) {
+				t.Errorf("Values in overflow pages should be loaded")
+			}
+		}
+	})
+	t.Run("Empty and closed tree", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		cursor := btree.Cursor()
+		if isFound, err := cursor.First(); err != nil || isFound {
+			t.Errorf("Item should not be found in empty tree")
+		}
+		if isFound, err := cursor.Seek(0); err != nil || isFound || cursor.Item() != nil {
+			t.Errorf("Item should not be found in empty tree")
+		}
+		if isFound, err := cursor.Next(); err != nil || isFound {
+			t.Errorf("Cursor which is not positioned should not move")
+		}
+
+		btree.Close()
+		if _, err := cursor.Last(); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
+
+func sortSearch(keys []int, key int) int {
+	index, _ := slices.BinarySearch(keys, key)
+	return index
+}
@@ -1,8 +1,12 @@
 package btree
 
+import "encoding/binary"
+
 type Element[T Item] struct {
-	item     *T
-	isClosed bool
+	item      *T
+	key       KeyType
+	isClosed  bool
+	overflows []*overflow
 }
 
 func newElement[T Item](item *T) *Element[T] {
@@ -12,12 +16,25 @@ func newElement[T Item](item *T) *Element[T] {
 	return element
 }
 
+// newKeyElement returns an element which has only key without item, used as a separator in internal nodes of B+tree.
+func newKeyElement[T Item](key KeyType) *Element[T] {
+	element := new(Element[T])
+	element.key = key
+	return element
+}
+
 func (element *Element[T]) getKey() KeyType {
+	if element.item == nil {
+		return element.key
+	}
 	return (*element.item).GetKey()
 }
 
 func (element *Element[T]) serialize() []byte {
-	buff := serializeItem(element.item)
+	if element.item == nil {
+		return appendUint64(make([]byte, 0, KEY_SIZE_BYTE), uint64(element.key))
+	}
+	buff := serializeItem(element.item, element.overflows)
 	if element.isClosed {
 		buff = append(buff, byte(1))
 	} else {
@@ -27,15 +44,23 @@ func (element *Element[T]) serialize() []byte {
 }
 
 func (element *Element[T]) deserialize(buff []byte) {
-	itemSize := calItemSize[T]()
-	element.item = deserializeItem[T](buff[:itemSize])
-	if int(buff[itemSize]) == 1 {
+	element.item, element.overflows = deserializeItem[T](buff[:len(buff)-1])
+	if int(buff[len(buff)-1]) == 1 {
 		element.isClosed = true
 	} else {
 		element.isClosed = false
 	}
 }
 
+func (element *Element[T]) deserializeKey(buff []byte) {
+	element.key = KeyType(binary.BigEndian.Uint64(buff))
+}
+
+func (element *Element[T]) sizeByte() int {
+	return len(element.serialize())
+}
+
+// calElementSize returns the largest size of serialized element.
 func calElementSize[T Item]() int {
 	return calItemSize[T]() + 1
 }
@@ -39,4 +39,13 @@ func TestElement(t *testing.T) {
 			t.Errorf("deserializedElement.isClosed should be true")
 		}
 	})
+	t.Run("Test sizeByte", func(t *testing.T) {
+		element := newElement(&Sample{String: "hello"})
+		if element.sizeByte() != len(element.serialize()) {
+			t.Errorf("sizeByte should be length of serialized element")
+		}
+		if element.sizeByte() != calElementSize[Sample]()-(DEFAULT_STRING_MAX_LENGTH-len("hello"))-16 {
+			t.Errorf("Strings should be stored at their real size")
+		}
+	})
 }
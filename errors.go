@@ -0,0 +1,55 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrIncompatibleFile = errors.New("Data file is incompatible")
+var ErrCorrupted = errors.New("Data file is corrupted")
+
+// ErrLocked is returned by New when another tree holds the lock of the data file.
+var ErrLocked = errors.New("Database is locked")
+var ErrReadOnly = errors.New("Tree is read-only")
+
+// IncompatibleFileError is returned when the header of an existing data file
+// does not match the format, degree or item type the tree is opened with.
+type IncompatibleFileError struct {
+	Field    string
+	Expected uint64
+	Actual   uint64
+}
+
+func (err *IncompatibleFileError) Error() string {
+	return fmt.Sprintf("Data file is incompatible: %s is %d but %d is expected", err.Field, err.Actual, err.Expected)
+}
+
+func (err *IncompatibleFileError) Unwrap() error {
+	return ErrIncompatibleFile
+}
+
+// CorruptedError is returned when a page read from the data file fails checksum verification.
+type CorruptedError struct {
+	Offset OffsetType
+}
+
+func (err *CorruptedError) Error() string {
+	return fmt.Sprintf("Data file is corrupted at offset %d", err.Offset)
+}
+
+func (err *CorruptedError) Unwrap() error {
+	return ErrCorrupted
+}
+
+// ReadOnlyError is returned when a tree opened by OpenReadOnly is modified.
+type ReadOnlyError struct {
+	Operation string
+}
+
+func (err *ReadOnlyError) Error() string {
+	return fmt.Sprintf("Tree is read-only: %s is not allowed", err.Operation)
+}
+
+func (err *ReadOnlyError) Unwrap() error {
+	return ErrReadOnly
+}
@@ -0,0 +1,87 @@
+package btree
+
+import (
+	"encoding/binary"
+)
+
+// Freed pages are chained into a list whose head is kept in the header.
+// Disk layout of free page: {pageType}{nextFreeOffset}{padding}{checksum}
+
+// allocate returns offset of a page for a new node, reusing a freed page if any.
+func (btree *BTree[T]) allocate() (OffsetType, error) {
+	if btree.isLatched() {
+		return 0, errRestart
+	}
+	if btree.isCopyOnWrite() {
+		return btree.allocateCopyOnWrite(), nil
+	}
+	offset := btree.header.freeOffset
+	if offset == 0 {
+		offset = btree.endOffset
+		btree.endOffset += OffsetType(btree.pageSize)
+		return offset, nil
+	}
+
+	nextOffset, err := btree.readFreePageFromDisk(offset)
+	if err != nil {
+		return 0, err
+	}
+	btree.header.freeOffset = nextOffset
+	if err = btree.writeHeaderToDisk(); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// free pushes the page at offset to the head of the free list.
+func (btree *BTree[T]) free(offset OffsetType) error {
+	if btree.isLatched() {
+		return errRestart
+	}
+	delete(btree.nodeCache, offset)
+	if btree.isCopyOnWrite() {
+		btree.freeCopyOnWrite(offset)
+		return nil
+	}
+	if err := btree.writeFreePageToDisk(offset, btree.header.freeOffset); err != nil {
+		return err
+	}
+	btree.header.freeOffset = offset
+	return btree.writeHeaderToDisk()
+}
+
+// unlinkFree removes the page at offset from the free list wherever it is.
+func (btree *BTree[T]) unlinkFree(offset OffsetType) error {
+	previousOffset := OffsetType(0)
+	currentOffset := btree.header.freeOffset
+	for currentOffset != 0 {
+		nextOffset, err := btree.readFreePageFromDisk(currentOffset)
+		if err != nil {
+			return err
+		}
+		if currentOffset == offset {
+			if previousOffset == 0 {
+				btree.header.freeOffset = nextOffset
+				return btree.writeHeaderToDisk()
+			}
+			return btree.writeFreePageToDisk(previousOffset, nextOffset)
+		}
+		previousOffset, currentOffset = currentOffset, nextOffset
+	}
+	return nil
+}
+
+func (btree *BTree[T]) readFreePageFromDisk(offset OffsetType) (OffsetType, error) {
+	buff := make([]byte, btree.pageSize)
+	if err := btree.readAt(buff, offset); err != nil || !verifyChecksum(buff) || buff[0] != PAGE_TYPE_FREE {
+		return 0, &CorruptedError{Offset: offset}
+	}
+	return OffsetType(binary.BigEndian.Uint64(buff[1 : 1+OFFSET_SIZE_BYTE])), nil
+}
+
+func (btree *BTree[T]) writeFreePageToDisk(offset OffsetType, nextOffset OffsetType) error {
+	buff := make([]byte, btree.pageSize-CHECKSUM_SIZE_BYTE)
+	buff[0] = PAGE_TYPE_FREE
+	binary.BigEndian.PutUint64(buff[1:1+OFFSET_SIZE_BYTE], uint64(nextOffset))
+	return btree.writeAt(appendChecksum(buff), offset)
+}
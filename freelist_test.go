@@ -0,0 +1,71 @@
+package btree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFreeList(t *testing.T) {
+	t.Run("Test allocate and free", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+
+		var first, second OffsetType
+		btree.operate(func(tree *BTree[Sample]) error {
+			first, _ = tree.allocate()
+			second, _ = tree.allocate()
+			tree.free(first)
+			return tree.free(second)
+		})
+		if second != first+OffsetType(btree.pageSize) {
+			t.Errorf("Pages should be allocated at the end of file")
+		}
+		btree.Close()
+
+		btree, err = New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		tree := btree.fork()
+		if offset, _ := tree.allocate(); offset != second {
+			t.Errorf("Last freed page should be reused first")
+		}
+		if offset, _ := tree.allocate(); offset != first {
+			t.Errorf("Freed page should be reused")
+		}
+		if offset, _ := tree.allocate(); offset != second+OffsetType(btree.pageSize) {
+			t.Errorf("Page should be allocated at the end of file when free list is empty")
+		}
+	})
+	t.Run("File size does not grow under churn", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		fileSize := int64(0)
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 200; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+			for i := 0; i < 200; i++ {
+				btree.Delete(KeyType(i))
+			}
+			file, _ := os.Stat(path)
+			if round > 0 && file.Size() > fileSize {
+				t.Errorf("File size should not grow after round %d", round)
+			}
+			fileSize = file.Size()
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package btree
+
+import (
+	"errors"
+)
+
+// Latched writers in WAL mode commit their pages in groups. A writer queues its fork, and the first writer
+// in the queue becomes the leader which waits for the previous group to be committed, then merges pages of
+// every writer queued until then and commits them with a single sync of the WAL and the data file.
+// Each writer returns after its group is committed, so it is as durable as a commit of its own.
+// Writers with exclusive access, which split or merge nodes or run in copy-on-write mode, build on the state
+// left by the previous writer, so they queue their functions instead, and the leader runs all of them on a single fork.
+
+type commitRequest[T Item] struct {
+	tree *BTree[T]
+	done chan error
+}
+
+type exclusiveRequest[T Item] struct {
+	fn   func(tree *BTree[T]) error
+	done chan error
+}
+
+// commitGroup commits pages written by the fork together with forks of other writers.
+// Pages of the forks do not overlap since each of them is latched by a single writer.
+func (btree *BTree[T]) commitGroup(tree *BTree[T]) error {
+	request := &commitRequest[T]{tree: tree, done: make(chan error, 1)}
+	btree.groupLock.Lock()
+	btree.commitQueue = append(btree.commitQueue, request)
+	isLeader := len(btree.commitQueue) == 1
+	btree.groupLock.Unlock()
+	if !isLeader {
+		return <-request.done
+	}
+
+	btree.commitLock.Lock()
+	defer btree.commitLock.Unlock()
+	btree.groupLock.Lock()
+	group := btree.commitQueue
+	btree.commitQueue = nil
+	btree.groupLock.Unlock()
+
+	btree.lock.RLock()
+	merged := btree.fork()
+	btree.lock.RUnlock()
+	for _, request := range group {
+		for offset, buff := range request.tree.dirtyPages {
+			merged.dirtyPages[offset] = buff
+		}
+	}
+	err := btree.commitFork(merged)
+	for _, request := range group[1:] {
+		request.done <- err
+	}
+	return err
+}
+
+// operateExclusive runs fn with exclusive access together with functions of other writers queued while the previous
+// writer holds writeLock. A function which fails is undone by restoring the fork to the savepoint taken before it,
+// and the others are committed at once.
+func (btree *BTree[T]) operateExclusive(fn func(tree *BTree[T]) error) error {
+	request := &exclusiveRequest[T]{fn: fn, done: make(chan error, 1)}
+	btree.groupLock.Lock()
+	btree.exclusiveQueue = append(btree.exclusiveQueue, request)
+	isLeader := len(btree.exclusiveQueue) == 1
+	btree.groupLock.Unlock()
+	if !isLeader {
+		return <-request.done
+	}
+
+	btree.writeLock.Lock()
+	defer btree.writeLock.Unlock()
+	btree.groupLock.Lock()
+	group := btree.exclusiveQueue
+	btree.exclusiveQueue = nil
+	btree.groupLock.Unlock()
+
+	errs := make([]error, len(group))
+	if btree.isOpen {
+		tree := btree.fork()
+		isModified := false
+		for i, request := range group {
+			savepoint := tree.captureSavepoint()
+			if errs[i] = request.fn(tree); errs[i] != nil {
+				tree.restoreSavepoint(savepoint)
+			} else {
+				isModified = true
+			}
+		}
+		if isModified {
+			if err := btree.commitFork(tree); err != nil {
+				for i := range errs {
+					if errs[i] == nil {
+						errs[i] = err
+					}
+				}
+			}
+		}
+	} else {
+		for i := range errs {
+			errs[i] = errors.New("Tree is closed")
+		}
+	}
+	for i, request := range group[1:] {
+		request.done <- errs[i+1]
+	}
+	return errs[0]
+}
@@ -0,0 +1,163 @@
+package btree
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGroupCommit(t *testing.T) {
+	t.Run("Concurrent writers are committed together", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		for i := 0; i < 1000; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		// Writers queue their pages while the previous group is being committed
+		btree.commitLock.Lock()
+		commitCount := btree.commitCount
+		done := make(chan error)
+		for i := 0; i < 1000; i += 100 {
+			go func(key int) {
+				done <- btree.Put(&Sample{Int: key, String: "updated"})
+			}(i)
+		}
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			btree.groupLock.Lock()
+			length := len(btree.commitQueue)
+			btree.groupLock.Unlock()
+			if length == 10 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Writers should be queued")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		btree.commitLock.Unlock()
+
+		for i := 0; i < 10; i++ {
+			if err := <-done; err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		if btree.commitCount != commitCount+1 {
+			t.Errorf("Writers should be committed at once")
+		}
+		btree.Close()
+
+		btree, _ = New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 1000; i++ {
+			item, err := btree.Get(KeyType(i))
+			if err != nil || (item.String == "updated") != (i%100 == 0) {
+				t.Errorf("Item should be updated by its writer")
+			}
+		}
+		checkTree(t, btree)
+	})
+	t.Run("Exclusive writers are committed together", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3, WithCopyOnWrite())
+		for i := 0; i < 1000; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		// Writers queue their functions while the previous writer holds the tree
+		btree.writeLock.Lock()
+		commitCount := btree.commitCount
+		done := make(chan error)
+		for i := 0; i < 1000; i += 100 {
+			go func(key int) {
+				done <- btree.Put(&Sample{Int: key, String: "updated"})
+			}(i)
+		}
+		go func() {
+			done <- btree.Delete(1000)
+		}()
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			btree.groupLock.Lock()
+			length := len(btree.exclusiveQueue)
+			btree.groupLock.Unlock()
+			if length == 11 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Writers should be queued")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		btree.writeLock.Unlock()
+
+		errCount := 0
+		for i := 0; i < 11; i++ {
+			if err := <-done; err != nil {
+				errCount += 1
+			}
+		}
+		if errCount != 1 {
+			t.Errorf("Error should be raised only for the failed writer")
+		}
+		if btree.commitCount != commitCount+1 {
+			t.Errorf("Writers should be committed at once")
+		}
+		btree.Close()
+
+		btree, _ = New[Sample](path, 3, WithCopyOnWrite())
+		defer btree.Close()
+		for i := 0; i < 1000; i++ {
+			item, err := btree.Get(KeyType(i))
+			if err != nil || (item.String == "updated") != (i%100 == 0) {
+				t.Errorf("Item should be updated by its writer")
+			}
+		}
+		checkTree(t, btree)
+	})
+	for name, opts := range map[string][]Option{"WAL": nil, "copy-on-write": {WithCopyOnWrite()}} {
+		t.Run(fmt.Sprintf("Concurrent Puts share syncs with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, 3, opts...)
+			for i := 0; i < 800; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+
+			// In WAL mode, updates neither split nor merge nodes, so that every writer runs with latches
+			commitCount := btree.commitCount
+			done := make(chan error)
+			for writer := 0; writer < 8; writer++ {
+				go func(writer int) {
+					for i := writer; i < 800; i += 8 {
+						if err := btree.Put(&Sample{Int: i, String: "updated"}); err != nil {
+							done <- err
+							return
+						}
+					}
+					done <- nil
+				}(writer)
+			}
+			for writer := 0; writer < 8; writer++ {
+				if err := <-done; err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			if btree.commitCount-commitCount >= 800 {
+				t.Errorf("Some writers should be committed together")
+			}
+			btree.Close()
+
+			btree, _ = New[Sample](path, 3, opts...)
+			defer btree.Close()
+			for i := 0; i < 800; i++ {
+				if item, err := btree.Get(KeyType(i)); err != nil || item.String != "updated" {
+					t.Errorf("Item should be updated")
+				}
+			}
+			checkTree(t, btree)
+		})
+	}
+}
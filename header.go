@@ -0,0 +1,88 @@
+package btree
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+type header struct {
+	magic       uint64
+	version     uint64
+	intSize     uint64
+	degree      uint64
+	pageSize    uint64
+	layout      uint64
+	journal     uint64
+	fingerprint uint64
+	rootOffset  OffsetType
+	freeOffset  OffsetType
+	txid        uint64
+}
+
+func newHeader[T Item](degree int, pageSize int, layout int, journal int) *header {
+	header := new(header)
+	header.magic = binary.BigEndian.Uint64([]byte(MAGIC))
+	header.version = FORMAT_VERSION
+	header.intSize = strconv.IntSize / 8
+	header.degree = uint64(degree)
+	header.pageSize = uint64(pageSize)
+	header.layout = uint64(layout)
+	header.journal = uint64(journal)
+	header.fingerprint = schemaFingerprint[T]()
+	return header
+}
+
+// Disk layout: {magic}{version}{intSize}{layout}{journal}{reserved}{degree}{pageSize}{fingerprint}{rootOffset}{freeOffset}{txid}{checksum}
+func (header *header) serialize() []byte {
+	buff := make([]byte, HEADER_SIZE_BYTE-CHECKSUM_SIZE_BYTE)
+	binary.BigEndian.PutUint64(buff[0:8], header.magic)
+	binary.BigEndian.PutUint16(buff[8:10], uint16(header.version))
+	buff[10] = byte(header.intSize)
+	buff[11] = byte(header.layout)
+	buff[12] = byte(header.journal)
+	binary.BigEndian.PutUint64(buff[16:24], header.degree)
+	binary.BigEndian.PutUint64(buff[24:32], header.pageSize)
+	binary.BigEndian.PutUint64(buff[32:40], header.fingerprint)
+	binary.BigEndian.PutUint64(buff[40:48], uint64(header.rootOffset))
+	binary.BigEndian.PutUint64(buff[48:56], uint64(header.freeOffset))
+	binary.BigEndian.PutUint64(buff[56:64], header.txid)
+	return appendChecksum(buff)
+}
+
+func (header *header) deserialize(buff []byte) {
+	header.magic = binary.BigEndian.Uint64(buff[0:8])
+	header.version = uint64(binary.BigEndian.Uint16(buff[8:10]))
+	header.intSize = uint64(buff[10])
+	header.layout = uint64(buff[11])
+	header.journal = uint64(buff[12])
+	header.degree = binary.BigEndian.Uint64(buff[16:24])
+	header.pageSize = binary.BigEndian.Uint64(buff[24:32])
+	header.fingerprint = binary.BigEndian.Uint64(buff[32:40])
+	header.rootOffset = OffsetType(binary.BigEndian.Uint64(buff[40:48]))
+	header.freeOffset = OffsetType(binary.BigEndian.Uint64(buff[48:56]))
+	header.txid = binary.BigEndian.Uint64(buff[56:64])
+}
+
+// validate checks that a header read from disk describes a file the expected header can work with.
+func (header *header) validate(expected *header) error {
+	fields := []struct {
+		name     string
+		expected uint64
+		actual   uint64
+	}{
+		{"magic", expected.magic, header.magic},
+		{"format version", expected.version, header.version},
+		{"int size", expected.intSize, header.intSize},
+		{"degree", expected.degree, header.degree},
+		{"page size", expected.pageSize, header.pageSize},
+		{"layout", expected.layout, header.layout},
+		{"journal mode", expected.journal, header.journal},
+		{"schema fingerprint", expected.fingerprint, header.fingerprint},
+	}
+	for _, field := range fields {
+		if field.expected != field.actual {
+			return &IncompatibleFileError{Field: field.name, Expected: field.expected, Actual: field.actual}
+		}
+	}
+	return nil
+}
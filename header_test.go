@@ -0,0 +1,66 @@
+package btree
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	t.Run("Test serialize and deserialize", func(t *testing.T) {
+		originalHeader := newHeader[Sample](DEFAULT_DEGREE, DEFAULT_PAGE_SIZE, LAYOUT_BTREE, JOURNAL_WAL)
+		originalHeader.rootOffset = 1024
+
+		deserializedHeader := new(header)
+		deserializedHeader.deserialize(originalHeader.serialize())
+
+		if *deserializedHeader != *originalHeader {
+			t.Errorf("deserializedHeader should be equal to header")
+		}
+	})
+	t.Run("Test validate", func(t *testing.T) {
+		header := newHeader[Sample](DEFAULT_DEGREE, DEFAULT_PAGE_SIZE, LAYOUT_BTREE, JOURNAL_WAL)
+		if err := header.validate(newHeader[Sample](DEFAULT_DEGREE, DEFAULT_PAGE_SIZE, LAYOUT_BTREE, JOURNAL_WAL)); err != nil {
+			t.Errorf("Error should not be raised")
+		}
+
+		var incompatibleFileError *IncompatibleFileError
+		err := header.validate(newHeader[Sample](DEFAULT_DEGREE+1, DEFAULT_PAGE_SIZE, LAYOUT_BTREE, JOURNAL_WAL))
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "degree" {
+			t.Errorf("IncompatibleFileError for degree should be raised")
+		}
+
+		err = header.validate(newHeader[Other](DEFAULT_DEGREE, DEFAULT_PAGE_SIZE, LAYOUT_BTREE, JOURNAL_WAL))
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "schema fingerprint" {
+			t.Errorf("IncompatibleFileError for schema fingerprint should be raised")
+		}
+		if !errors.Is(err, ErrIncompatibleFile) {
+			t.Errorf("Error should be ErrIncompatibleFile")
+		}
+	})
+	t.Run("Test schemaFingerprint", func(t *testing.T) {
+		if schemaFingerprint[Sample]() != schemaFingerprint[Sample]() {
+			t.Errorf("Fingerprint should be stable")
+		}
+		if schemaFingerprint[Other]() == schemaFingerprint[Renamed]() {
+			t.Errorf("Fingerprint should differ when field names differ")
+		}
+	})
+}
+
+type Other struct {
+	ID   int
+	Name string `maxLength:"32"`
+}
+
+func (item Other) GetKey() int64 {
+	return int64(item.ID)
+}
+
+type Renamed struct {
+	ID    int
+	Title string `maxLength:"32"`
+}
+
+func (item Renamed) GetKey() int64 {
+	return int64(item.ID)
+}
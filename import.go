@@ -0,0 +1,227 @@
+package btree
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/exp/slices"
+)
+
+// Import creates a tree at path from items given by next in any order. When an item has the same key as
+// an earlier one, the later item is kept like Put. Items are sorted in runs of up to runSize items, and runs are spilled
+// to temporary files next to the data file and merged into BulkLoad, so that only one run is held in memory.
+func Import[T Item](path string, degree int, next Iterator[T], fillFactor float64, runSize int, opts ...Option) (*BTree[T], error) {
+	if runSize < 1 {
+		return nil, errors.New("Parameter 'runSize' should be greater than 0")
+	}
+	if err := isNewDataFile(path); err != nil {
+		return nil, err
+	}
+	importer := newImporter[T](path, runSize)
+	defer importer.close()
+	merged, err := importer.sort(next)
+	if err != nil {
+		return nil, err
+	}
+	return BulkLoad[T](path, degree, latest(merged), fillFactor, opts...)
+}
+
+// importer spills sorted runs in the format of {length}{item}, where values are never stored in overflow pages.
+type importer[T Item] struct {
+	path    string
+	runSize int
+	runs    []*os.File
+}
+
+func newImporter[T Item](path string, runSize int) *importer[T] {
+	importer := new(importer[T])
+	importer.path = path
+	importer.runSize = runSize
+	return importer
+}
+
+// sort returns an iterator of items in ascending order of keys, where items with the same key are in the given order.
+func (importer *importer[T]) sort(next Iterator[T]) (Iterator[T], error) {
+	items := make([]*T, 0, importer.runSize)
+	for {
+		item, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			break
+		}
+		items = append(items, item)
+		if len(items) == importer.runSize {
+			if err = importer.spill(items); err != nil {
+				return nil, err
+			}
+			items = items[:0]
+		}
+	}
+
+	sortItems(items)
+	if len(importer.runs) == 0 {
+		// Every item fits in a run, so that nothing needs to be spilled
+		return func() (*T, error) {
+			if len(items) == 0 {
+				return nil, nil
+			}
+			item := items[0]
+			items = items[1:]
+			return item, nil
+		}, nil
+	}
+	if len(items) > 0 {
+		if err := importer.spill(items); err != nil {
+			return nil, err
+		}
+	}
+	return importer.merge()
+}
+
+func (importer *importer[T]) spill(items []*T) error {
+	fp, err := os.CreateTemp(filepath.Dir(importer.path), filepath.Base(importer.path)+".run*")
+	if err != nil {
+		return err
+	}
+	importer.runs = append(importer.runs, fp)
+
+	sortItems(items)
+	writer := bufio.NewWriter(fp)
+	for _, item := range items {
+		buff := serializeItem(item, nil)
+		if _, err = writer.Write(appendUint32(nil, uint32(len(buff)))); err != nil {
+			return err
+		}
+		if _, err = writer.Write(buff); err != nil {
+			return err
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	_, err = fp.Seek(0, io.SeekStart)
+	return err
+}
+
+// merge returns an iterator which merges runs by a heap of their first items.
+// Items with the same key are returned in the order of runs, which is the order they were given.
+func (importer *importer[T]) merge() (Iterator[T], error) {
+	readers := []*bufio.Reader{}
+	entries := &mergeHeap[T]{}
+	for i, fp := range importer.runs {
+		readers = append(readers, bufio.NewReader(fp))
+		item, err := readRun[T](readers[i])
+		if err != nil {
+			return nil, err
+		}
+		heap.Push(entries, &mergeEntry[T]{item: item, run: i})
+	}
+	return func() (*T, error) {
+		if entries.Len() == 0 {
+			return nil, nil
+		}
+		entry := heap.Pop(entries).(*mergeEntry[T])
+		item, err := readRun[T](readers[entry.run])
+		if err != nil {
+			return nil, err
+		}
+		if item != nil {
+			heap.Push(entries, &mergeEntry[T]{item: item, run: entry.run})
+		}
+		return entry.item, nil
+	}, nil
+}
+
+func (importer *importer[T]) close() {
+	for _, fp := range importer.runs {
+		fp.Close()
+		os.Remove(fp.Name())
+	}
+	importer.runs = nil
+}
+
+// readRun returns the next item of a run, and nil at the end of it.
+func readRun[T Item](reader *bufio.Reader) (*T, error) {
+	length := make([]byte, 4)
+	if _, err := io.ReadFull(reader, length); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	buff := make([]byte, binary.BigEndian.Uint32(length))
+	if _, err := io.ReadFull(reader, buff); err != nil {
+		return nil, err
+	}
+	item, _ := deserializeItem[T](buff)
+	return item, nil
+}
+
+func sortItems[T Item](items []*T) {
+	slices.SortStableFunc(items, func(a, b *T) bool {
+		return (*a).GetKey() < (*b).GetKey()
+	})
+}
+
+// latest returns an iterator which returns only the last of consecutive items with the same key.
+func latest[T Item](next Iterator[T]) Iterator[T] {
+	var pending *T
+	return func() (*T, error) {
+		for {
+			item, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if pending == nil {
+				if item == nil {
+					return nil, nil
+				}
+				pending = item
+				continue
+			}
+			if item == nil || (*item).GetKey() != (*pending).GetKey() {
+				result := pending
+				pending = item
+				return result, nil
+			}
+			pending = item
+		}
+	}
+}
+
+type mergeEntry[T Item] struct {
+	item *T
+	run  int
+}
+
+type mergeHeap[T Item] []*mergeEntry[T]
+
+func (entries mergeHeap[T]) Len() int {
+	return len(entries)
+}
+
+func (entries mergeHeap[T]) Less(i, j int) bool {
+	iKey, jKey := (*entries[i].item).GetKey(), (*entries[j].item).GetKey()
+	return iKey < jKey || (iKey == jKey && entries[i].run < entries[j].run)
+}
+
+func (entries mergeHeap[T]) Swap(i, j int) {
+	entries[i], entries[j] = entries[j], entries[i]
+}
+
+func (entries *mergeHeap[T]) Push(entry any) {
+	*entries = append(*entries, entry.(*mergeEntry[T]))
+}
+
+func (entries *mergeHeap[T]) Pop() any {
+	old := *entries
+	entry := old[len(old)-1]
+	*entries = old[:len(old)-1]
+	return entry
+}
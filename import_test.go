@@ -0,0 +1,89 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	for _, runSize := range []int{1, 7, 100, 1000} {
+		t.Run(fmt.Sprintf("Import -> Reopen with run size %d", runSize), func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, DEFAULT_DATA_PATH)
+			random := rand.New(rand.NewSource(int64(runSize)))
+
+			// Every key is given twice and the later document should be kept
+			documents := []*Document{}
+			for _, key := range append(random.Perm(300), random.Perm(300)...) {
+				documents = append(documents, newDocument(key, len(documents)))
+			}
+			expected := map[int]*Document{}
+			for _, document := range documents {
+				expected[document.ID] = document
+			}
+
+			btree, err := Import[Document](path, 3, newSliceIterator(documents), 0.9, runSize)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			btree.Close()
+			if runs, _ := filepath.Glob(path + ".run*"); len(runs) != 0 {
+				t.Errorf("Runs should be removed")
+			}
+
+			btree, err = New[Document](path, 3)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+			for key, document := range expected {
+				if item, err := btree.Get(KeyType(key)); err != nil || !isSameDocument(item, document) {
+					t.Errorf("The last document %d should be found", key)
+				}
+			}
+			if keys := checkTree(t, btree); len(keys) != 300 {
+				t.Errorf("Tree should have 300 keys")
+			}
+		})
+	}
+	t.Run("Failed import removes runs", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, DEFAULT_DATA_PATH)
+
+		failure := errors.New("failure")
+		count := 0
+		failing := func() (*Sample, error) {
+			if count == 50 {
+				return nil, failure
+			}
+			count += 1
+			return &Sample{Int: 100 - count}, nil
+		}
+		if _, err := Import[Sample](path, DEFAULT_DEGREE, failing, 1, 10); !errors.Is(err, failure) {
+			t.Errorf("Error of iterator should be raised")
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("Runs should be removed")
+		}
+	})
+	t.Run("Invalid parameters", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		if _, err := Import[Sample](path, DEFAULT_DEGREE, newSliceIterator([]*Sample{}), 1, 0); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := Import[Sample](path, DEFAULT_DEGREE, newSliceIterator([]*Sample{}), 0, 10); err == nil {
+			t.Errorf("Error should be raised")
+		}
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		btree.Close()
+		if _, err := Import[Sample](path, DEFAULT_DEGREE, newSliceIterator([]*Sample{}), 1, 10); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
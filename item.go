@@ -4,10 +4,10 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"golang.org/x/exp/slices"
 )
@@ -16,11 +16,12 @@ type Item interface {
 	GetKey() KeyType
 }
 
-func serializeItem[T Item](item *T) []byte {
-	buff := make([]byte, calItemSize[T]())
-	buffPtr := 0
+// Strings and byte slices are stored with their length instead of being padded to maxLength.
+// Values which have overflows are stored as references to overflow pages.
+// Disk layout of variable length field: {isOverflow}{length}{value or overflowOffset}
+func serializeItem[T Item](item *T, overflows []*overflow) []byte {
+	buff := make([]byte, 0, calItemSize[T]())
 	itemVal := reflect.ValueOf(item).Elem()
-	itemType := reflect.TypeOf(*item)
 	for i := 0; i < itemVal.NumField(); i++ {
 		field := itemVal.FieldByIndex([]int{i})
 		fieldType := field.Type().Kind()
@@ -28,57 +29,52 @@ func serializeItem[T Item](item *T) []byte {
 		if !field.CanSet() {
 			continue
 		} else if fieldType == reflect.Int8 || (fieldType == reflect.Int && fieldSize == 1) {
-			buff[buffPtr] = byte(field.Int())
-			buffPtr += 1
+			buff = append(buff, byte(field.Int()))
 		} else if fieldType == reflect.Int16 || (fieldType == reflect.Int && fieldSize == 2) {
-			binary.BigEndian.PutUint16(buff[buffPtr:buffPtr+2], uint16(field.Int()))
-			buffPtr += 2
+			buff = appendUint16(buff, uint16(field.Int()))
 		} else if fieldType == reflect.Int32 || (fieldType == reflect.Int && fieldSize == 4) {
-			binary.BigEndian.PutUint32(buff[buffPtr:buffPtr+4], uint32(field.Int()))
-			buffPtr += 4
+			buff = appendUint32(buff, uint32(field.Int()))
 		} else if fieldType == reflect.Int64 || (fieldType == reflect.Int && fieldSize == 8) {
-			binary.BigEndian.PutUint64(buff[buffPtr:buffPtr+8], uint64(field.Int()))
-			buffPtr += 8
+			buff = appendUint64(buff, uint64(field.Int()))
 		} else if fieldType == reflect.Uint8 || (fieldType == reflect.Uint && fieldSize == 1) {
-			buff[buffPtr] = byte(field.Uint())
-			buffPtr += 1
+			buff = append(buff, byte(field.Uint()))
 		} else if fieldType == reflect.Uint16 || (fieldType == reflect.Uint && fieldSize == 2) {
-			binary.BigEndian.PutUint16(buff[buffPtr:buffPtr+2], uint16(field.Uint()))
-			buffPtr += 2
+			buff = appendUint16(buff, uint16(field.Uint()))
 		} else if fieldType == reflect.Uint32 || (fieldType == reflect.Uint && fieldSize == 4) {
-			binary.BigEndian.PutUint32(buff[buffPtr:buffPtr+4], uint32(field.Uint()))
-			buffPtr += 4
+			buff = appendUint32(buff, uint32(field.Uint()))
 		} else if fieldType == reflect.Uint64 || (fieldType == reflect.Uint && fieldSize == 8) {
-			binary.BigEndian.PutUint64(buff[buffPtr:buffPtr+8], uint64(field.Uint()))
-			buffPtr += 8
+			buff = appendUint64(buff, uint64(field.Uint()))
 		} else if fieldType == reflect.Float32 {
-			binary.BigEndian.PutUint32(buff[buffPtr:buffPtr+4], math.Float32bits(float32(field.Float())))
-			buffPtr += 4
+			buff = appendUint32(buff, math.Float32bits(float32(field.Float())))
 		} else if fieldType == reflect.Float64 {
-			binary.BigEndian.PutUint64(buff[buffPtr:buffPtr+8], math.Float64bits(field.Float()))
-			buffPtr += 8
+			buff = appendUint64(buff, math.Float64bits(field.Float()))
 		} else if fieldType == reflect.Bool {
 			if field.Bool() {
-				buff[buffPtr] = byte(1)
+				buff = append(buff, byte(1))
 			} else {
-				buff[buffPtr] = byte(0)
+				buff = append(buff, byte(0))
 			}
-			buffPtr += 1
-		} else if fieldType == reflect.String {
-			maxLength, _ := getMaxStringLength(itemType.Field(i).Tag.Get("maxLength"))
-			for _, each := range []byte(padSpaces(field.String(), maxLength)) {
-				buff[buffPtr] = each
-				buffPtr += 1
+		} else if isVariableLength(field.Type()) {
+			if overflow := findOverflow(overflows, i); overflow != nil {
+				buff = append(buff, byte(1))
+				buff = appendUint32(buff, uint32(overflow.length))
+				buff = appendUint64(buff, uint64(overflow.offset))
+			} else {
+				value := getVariableLengthValue(field)
+				buff = append(buff, byte(0))
+				buff = appendUint32(buff, uint32(len(value)))
+				buff = append(buff, value...)
 			}
 		}
 	}
 	return buff
 }
 
-func deserializeItem[T Item](buff []byte) *T {
+// deserializeItem leaves fields stored in overflow pages empty and returns references to them instead.
+func deserializeItem[T Item](buff []byte) (*T, []*overflow) {
+	overflows := []*overflow{}
 	item := new(T)
 	itemVal := reflect.ValueOf(item).Elem()
-	itemType := reflect.TypeOf(*item)
 	var buffPtr uint64 = 0
 	for i := 0; i < itemVal.NumField(); i++ {
 		field := itemVal.FieldByIndex([]int{i})
@@ -124,15 +120,25 @@ func deserializeItem[T Item](buff []byte) *T {
 				field.SetBool(false)
 			}
 			buffPtr += 1
-		} else if fieldType == reflect.String {
-			maxLength, _ := getMaxStringLength(itemType.Field(i).Tag.Get("maxLength"))
-			field.SetString(strings.TrimSpace(string(buff[buffPtr : buffPtr+uint64(maxLength)])))
-			buffPtr += uint64(maxLength)
+		} else if isVariableLength(field.Type()) {
+			isOverflow := int(buff[buffPtr]) == 1
+			length := uint64(binary.BigEndian.Uint32(buff[buffPtr+1 : buffPtr+1+STRING_LENGTH_BYTE]))
+			buffPtr += 1 + STRING_LENGTH_BYTE
+			if isOverflow {
+				offset := OffsetType(binary.BigEndian.Uint64(buff[buffPtr : buffPtr+OFFSET_SIZE_BYTE]))
+				overflows = append(overflows, &overflow{field: i, length: int(length), offset: offset})
+				buffPtr += OFFSET_SIZE_BYTE
+			} else {
+				setVariableLengthValue(field, buff[buffPtr:buffPtr+length])
+				buffPtr += length
+			}
 		}
 	}
-	return item
+	return item, overflows
 }
 
+// calItemSize returns the largest size of serialized item, where every string is as long as its maxLength
+// or stored in overflow pages.
 func calItemSize[T Item]() int {
 	size := 0
 	item := new(T)
@@ -140,13 +146,15 @@ func calItemSize[T Item]() int {
 	itemType := reflect.TypeOf(*item)
 	for i := 0; i < itemVal.NumField(); i++ {
 		field := itemVal.FieldByIndex([]int{i})
-		fieldType := field.Type().Kind()
 		fieldSize := field.Type().Size()
 		if !field.CanSet() {
 			continue
-		} else if fieldType == reflect.String {
+		} else if isVariableLength(field.Type()) {
 			maxLength, _ := getMaxStringLength(itemType.Field(i).Tag.Get("maxLength"))
-			size += maxLength
+			if maxLength > INLINE_MAX_LENGTH {
+				maxLength = INLINE_MAX_LENGTH
+			}
+			size += 1 + STRING_LENGTH_BYTE + maxLength
 		} else {
 			size += int(fieldSize)
 		}
@@ -154,6 +162,27 @@ func calItemSize[T Item]() int {
 	return size
 }
 
+// schemaFingerprint hashes name, kind, size and maxLength of every stored field,
+// so that a data file written for a different item type can be detected.
+func schemaFingerprint[T Item]() uint64 {
+	hash := fnv.New64a()
+	item := new(T)
+	itemVal := reflect.ValueOf(item).Elem()
+	itemType := reflect.TypeOf(*item)
+	for i := 0; i < itemVal.NumField(); i++ {
+		field := itemVal.FieldByIndex([]int{i})
+		if !field.CanSet() {
+			continue
+		}
+		size := int(field.Type().Size())
+		if isVariableLength(field.Type()) {
+			size, _ = getMaxStringLength(itemType.Field(i).Tag.Get("maxLength"))
+		}
+		fmt.Fprintf(hash, "%s:%s:%d;", itemType.Field(i).Name, field.Type().Kind(), size)
+	}
+	return hash.Sum64()
+}
+
 func isValidItemFields[T Item]() error {
 	itemVal := reflect.ValueOf(new(T)).Elem()
 	for i := 0; i < itemVal.NumField(); i++ {
@@ -161,6 +190,9 @@ func isValidItemFields[T Item]() error {
 		if !field.CanSet() {
 			continue
 		}
+		if field.Type().Kind() == reflect.Slice && !isVariableLength(field.Type()) {
+			return errors.New(fmt.Sprintf("Type []%s is not allowed", field.Type().Elem().Kind()))
+		}
 		if slices.Contains(AVAILABLE_TYPES, field.Type().Kind()) {
 			continue
 		}
@@ -182,28 +214,61 @@ func isValidStringLabel[T Item]() error {
 	return nil
 }
 
+// isValidStringLength checks string and byte slice fields which have maxLength label.
+// Fields without the label have no limit because long values are stored in overflow pages.
 func isValidStringLength[T Item](item *T) error {
 	itemVal := reflect.ValueOf(item).Elem()
 	itemType := reflect.TypeOf(*item)
 	for i := 0; i < itemVal.NumField(); i++ {
 		field := itemVal.FieldByIndex([]int{i})
-		if !field.CanSet() {
+		maxLengthLabel := itemType.Field(i).Tag.Get("maxLength")
+		if !field.CanSet() || !isVariableLength(field.Type()) || maxLengthLabel == "" {
 			continue
 		}
-		maxLength, _ := getMaxStringLength(itemType.Field(i).Tag.Get("maxLength"))
-		if field.Type().Kind() == reflect.String && (len(field.String()) > maxLength) {
+		maxLength, _ := getMaxStringLength(maxLengthLabel)
+		if len(getVariableLengthValue(field)) > maxLength {
 			return errors.New(fmt.Sprintf("Length of string field should be less than %d", maxLength))
 		}
 	}
 	return nil
 }
 
-func padSpaces(v string, maxLength int) string {
-	numberOfPaddings := maxLength - len(v)
-	for i := 0; i < numberOfPaddings; i++ {
-		v += " "
+// isVariableLength reports whether values of the type are strings or byte slices, which have no fixed size.
+func isVariableLength(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.String || (fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8)
+}
+
+func getVariableLengthValue(field reflect.Value) []byte {
+	if field.Kind() == reflect.String {
+		return []byte(field.String())
+	}
+	return field.Bytes()
+}
+
+func setVariableLengthValue(field reflect.Value, value []byte) {
+	if field.Kind() == reflect.String {
+		field.SetString(string(value))
+	} else {
+		field.SetBytes(append([]byte{}, value...))
 	}
-	return v
+}
+
+func appendUint16(buff []byte, v uint16) []byte {
+	bytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(bytes, v)
+	return append(buff, bytes...)
+}
+
+func appendUint32(buff []byte, v uint32) []byte {
+	bytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(bytes, v)
+	return append(buff, bytes...)
+}
+
+func appendUint64(buff []byte, v uint64) []byte {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, v)
+	return append(buff, bytes...)
 }
 
 func getMaxStringLength(label string) (int, error) {
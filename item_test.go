@@ -33,22 +33,20 @@ type InvalidSample struct {
 	Strings []string
 }
 
+type InvalidSliceSample struct {
+	Int  int
+	Ints []int
+}
+
+func (item InvalidSliceSample) GetKey() int64 {
+	return int64(item.Int)
+}
+
 func (item InvalidSample) GetKey() int64 {
 	return int64(item.Int)
 }
 
 func TestItem(t *testing.T) {
-	t.Run("Test padSpaces", func(t *testing.T) {
-		paddedString := padSpaces("hello", 10)
-		if paddedString != "hello     " {
-			t.Errorf("paddedString should be 'hello     '")
-		}
-
-		paddedString = padSpaces("hello", 5)
-		if paddedString != "hello" {
-			t.Errorf("paddedString should be 'hello     '")
-		}
-	})
 	t.Run("Test getMaxStringLength", func(t *testing.T) {
 		maxLength, err := getMaxStringLength("")
 		if err != nil {
@@ -85,7 +83,7 @@ func TestItem(t *testing.T) {
 		str := "hello, world"
 		item := new(Sample)
 		item.String = str
-		deserializedItem := deserializeItem[Sample](serializeItem(item))
+		deserializedItem, _ := deserializeItem[Sample](serializeItem(item, nil))
 		if deserializedItem.String != str {
 			t.Errorf("string field should be %s", str)
 		}
@@ -97,6 +95,12 @@ func TestItem(t *testing.T) {
 		if err := isValidItemFields[InvalidSample](); err == nil {
 			t.Errorf("Error should be raised")
 		}
+		if err := isValidItemFields[InvalidSliceSample](); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if err := isValidItemFields[Document](); err != nil {
+			t.Errorf("Error should not be raised")
+		}
 	})
 	t.Run("Test isValidStringLabel", func(t *testing.T) {
 		if err := isValidStringLabel[InvalidSample](); err == nil {
@@ -0,0 +1,133 @@
+package btree
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// Put and Delete first run with latches on nodes, so that writers to different subtrees run in parallel.
+// A writer latches nodes from the root while traversing and releases latches of ancestors once a child is known
+// not to split or merge. Since the header and the free list are not latched, an operation which allocates or frees
+// a page, or reads or writes a node which is not latched, is restarted with exclusive access to the tree.
+var errRestart = errors.New("Operation should be restarted with exclusive access")
+
+type latchTable struct {
+	lock    sync.Mutex
+	latches map[OffsetType]*latch
+}
+
+// latch is removed from the table when no writer holds or waits for it.
+type latch struct {
+	lock  sync.Mutex
+	count int
+}
+
+func newLatchTable() *latchTable {
+	table := new(latchTable)
+	table.latches = map[OffsetType]*latch{}
+	return table
+}
+
+func (table *latchTable) acquire(offset OffsetType) {
+	table.lock.Lock()
+	entry, ok := table.latches[offset]
+	if !ok {
+		entry = new(latch)
+		table.latches[offset] = entry
+	}
+	entry.count += 1
+	table.lock.Unlock()
+	entry.lock.Lock()
+}
+
+func (table *latchTable) release(offset OffsetType) {
+	table.lock.Lock()
+	defer table.lock.Unlock()
+	entry := table.latches[offset]
+	entry.lock.Unlock()
+	entry.count -= 1
+	if entry.count == 0 {
+		delete(table.latches, offset)
+	}
+}
+
+// operateLatched runs fn on a fork which latches nodes it traverses, and commits it with other latched writers.
+// Latches are held until pages are committed so that the next writer reads them from the data file.
+func (btree *BTree[T]) operateLatched(fn func(tree *BTree[T]) error, isSafe func(tree *BTree[T], node *Node[T]) bool) error {
+	btree.lock.RLock()
+	if !btree.isOpen {
+		btree.lock.RUnlock()
+		return errors.New("Tree is closed")
+	}
+	tree := btree.fork()
+	btree.lock.RUnlock()
+
+	tree.isSafe = isSafe
+	defer func() {
+		tree.releaseLatches(len(tree.heldLatches))
+	}()
+	if err := fn(tree); err != nil {
+		return err
+	}
+	return btree.commitGroup(tree)
+}
+
+// latchNode latches the node at offset before it is read by traverse.
+func (btree *BTree[T]) latchNode(offset OffsetType) {
+	if !btree.isLatched() || slices.Contains(btree.heldLatches, offset) {
+		return
+	}
+	btree.latches.acquire(offset)
+	btree.heldLatches = append(btree.heldLatches, offset)
+}
+
+// releaseAncestors releases latches of nodes traversed before node if node does not split or merge.
+func (btree *BTree[T]) releaseAncestors(node *Node[T]) {
+	if btree.isLatched() && btree.isSafe(btree, node) {
+		btree.releaseLatches(len(btree.heldLatches) - 1)
+	}
+}
+
+// releaseLatches releases the first count latches held by the tree.
+func (btree *BTree[T]) releaseLatches(count int) {
+	for _, offset := range btree.heldLatches[:count] {
+		btree.latches.release(offset)
+	}
+	btree.heldLatches = btree.heldLatches[count:]
+}
+
+// checkLatch returns errRestart if the tree is latched but the page at offset is not.
+func (btree *BTree[T]) checkLatch(offset OffsetType) error {
+	if btree.isLatched() && !slices.Contains(btree.heldLatches, offset) {
+		return errRestart
+	}
+	return nil
+}
+
+// isLatched reports whether the operation is run by a latched writer, which is false for the tree shared by readers.
+func (operation *operation[T]) isLatched() bool {
+	return operation != nil && operation.isSafe != nil
+}
+
+// isSafeForPut reports whether node does not split even if an element of the largest size is added,
+// which also covers an element growing by update, and does not merge even if an element is updated to the smallest size.
+func (btree *BTree[T]) isSafeForPut(node *Node[T]) bool {
+	if btree.degree != PAGE_DEGREE && len(node.elements)+1 > btree.maxElements()-1 {
+		return false
+	}
+	size := node.bodySizeByte()
+	if btree.degree == PAGE_DEGREE && size-calElementSize[T]() < btree.nodeCapacity()/4 {
+		return false
+	}
+	return size+SLOT_SIZE_BYTE+calElementSize[T]()+OFFSET_SIZE_BYTE <= btree.nodeCapacity()
+}
+
+// isSafeForDelete reports whether node does not merge even if an element of the largest size is removed.
+func (btree *BTree[T]) isSafeForDelete(node *Node[T]) bool {
+	if btree.degree != PAGE_DEGREE {
+		return len(node.elements)-1 >= btree.minElements()
+	}
+	return node.bodySizeByte()-SLOT_SIZE_BYTE-calElementSize[T]()-OFFSET_SIZE_BYTE >= btree.nodeCapacity()/4
+}
@@ -0,0 +1,130 @@
+package btree
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatch(t *testing.T) {
+	t.Run("Writers to different leaves run in parallel", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 200; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		// Hold the latch of the leaf of key 0 as if another writer were modifying it
+		_, traversedNodes, _, _ := btree.traverse(0)
+		leafOffset := traversedNodes[len(traversedNodes)-1].offset
+		btree.latches.acquire(leafOffset)
+
+		done := make(chan error)
+		go func() {
+			done <- btree.Put(&Sample{Int: 150, String: "updated"})
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Writer to another leaf should not wait")
+		}
+
+		go func() {
+			done <- btree.Put(&Sample{Int: 0, String: "updated"})
+		}()
+		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-done:
+			t.Errorf("Writer to the latched leaf should wait")
+		default:
+		}
+		btree.latches.release(leafOffset)
+		if err := <-done; err != nil {
+			t.Errorf("Error should not be raised")
+		}
+
+		for _, key := range []KeyType{0, 150} {
+			if item, err := btree.Get(key); err != nil || item.String != "updated" {
+				t.Errorf("Item should be updated")
+			}
+		}
+		if len(btree.latches.latches) != 0 {
+			t.Errorf("Latches should be released")
+		}
+	})
+	t.Run("Split and merge are restarted with exclusive access", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 2)
+		defer btree.Close()
+
+		tree := btree.fork()
+		tree.isSafe = (*BTree[Sample]).isSafeForPut
+		for i := 0; i < 2; i++ {
+			if err := tree.put(&Sample{Int: i}); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+		}
+		// The root of degree 2 splits on the third element
+		if err := tree.put(&Sample{Int: 2}); err != errRestart {
+			t.Errorf("Operation should be restarted")
+		}
+		tree.releaseLatches(len(tree.heldLatches))
+
+		for i := 0; i < 100; i++ {
+			if err := btree.Put(&Sample{Int: i}); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		for i := 0; i < 100; i++ {
+			if err := btree.Delete(KeyType(i)); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		if len(btree.latches.latches) != 0 {
+			t.Errorf("Latches should be released")
+		}
+		checkTree(t, btree)
+	})
+	t.Run("Updates which change the size of nodes in parallel", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, PAGE_DEGREE)
+		defer btree.Close()
+		for i := 0; i < 400; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		done := make(chan error)
+		for writer := 0; writer < 4; writer++ {
+			go func(writer int) {
+				for _, length := range []int{250, 0, 250} {
+					for i := writer; i < 400; i += 4 {
+						if err := btree.Put(&Sample{Int: i, String: strings.Repeat("a", length)}); err != nil {
+							done <- err
+							return
+						}
+					}
+				}
+				done <- nil
+			}(writer)
+		}
+		for writer := 0; writer < 4; writer++ {
+			if err := <-done; err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		if len(checkTree(t, btree)) != 400 {
+			t.Errorf("Tree should have 400 keys")
+		}
+		if len(btree.latches.latches) != 0 {
+			t.Errorf("Latches should be released")
+		}
+	})
+}
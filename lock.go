@@ -0,0 +1,26 @@
+package btree
+
+import (
+	"os"
+	"time"
+)
+
+// lockFile takes an advisory lock of the data file, which is shared between readers or held by a single writer.
+// It waits up to timeout while another process or tree holds a conflicting lock, and returns ErrLocked after that.
+// The lock is released when the file is closed.
+func lockFile(fp *os.File, isShared bool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		isLocked, err := tryLockFile(fp, isShared)
+		if err != nil {
+			return err
+		}
+		if isLocked {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(LOCK_RETRY_INTERVAL)
+	}
+}
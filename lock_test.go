@@ -0,0 +1,65 @@
+package btree
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile(t *testing.T) {
+	t.Run("Second tree can not open locked file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if _, err = New[Sample](path, DEFAULT_DEGREE); !errors.Is(err, ErrLocked) {
+			t.Errorf("ErrLocked should be raised")
+		}
+
+		btree.Close()
+		btree, err = New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Lock should be released by Close")
+		}
+		btree.Close()
+	})
+	t.Run("Wait for lock with timeout", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		start := time.Now()
+		if _, err := New[Sample](path, DEFAULT_DEGREE, WithLockTimeout(100*time.Millisecond)); !errors.Is(err, ErrLocked) {
+			t.Errorf("ErrLocked should be raised")
+		}
+		if time.Since(start) < 100*time.Millisecond {
+			t.Errorf("Lock should be waited until timeout")
+		}
+
+		held := btree
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			held.Close()
+		}()
+		btree, err := New[Sample](path, DEFAULT_DEGREE, WithLockTimeout(5*time.Second))
+		if err != nil {
+			t.Fatalf("Lock should be taken after it is released")
+		}
+		btree.Close()
+	})
+	t.Run("Compacted file is locked", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		btree.Put(&Sample{Int: 1})
+		if err := btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		if _, err := New[Sample](path, DEFAULT_DEGREE); !errors.Is(err, ErrLocked) {
+			t.Errorf("ErrLocked should be raised")
+		}
+	})
+}
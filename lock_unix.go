@@ -0,0 +1,20 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package btree
+
+import (
+	"os"
+	"syscall"
+)
+
+func tryLockFile(fp *os.File, isShared bool) (bool, error) {
+	how := syscall.LOCK_EX
+	if isShared {
+		how = syscall.LOCK_SH
+	}
+	err := syscall.Flock(int(fp.Fd()), how|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
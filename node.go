@@ -11,6 +11,8 @@ type Node[T Item] struct {
 	offset       OffsetType
 	elements     []*Element[T]
 	childOffsets []OffsetType
+	prevOffset   OffsetType
+	nextOffset   OffsetType
 }
 
 func newNode[T Item](offset OffsetType) *Node[T] {
@@ -19,71 +21,75 @@ func newNode[T Item](offset OffsetType) *Node[T] {
 	return node
 }
 
-func nodSizeByte[T Item](maxElements int) int {
-	return metadataSizeByte() + totalElementSizeByte[T](maxElements) + totalChildOffsetSizeByte[T](maxElements)
-}
-
-func metadataSizeByte() int {
-	return LENGTH_IN_NODE_BYTE + LENGTH_IN_NODE_BYTE
-}
-
-func totalElementSizeByte[T Item](maxElements int) int {
-	elementSize := calElementSize[T]()
-	return elementSize * (maxElements - 1)
-}
-
-func totalChildOffsetSizeByte[T Item](maxElements int) int {
-	return OFFSET_SIZE_BYTE * maxElements
-}
-
-// Disk layout: {elementLength}{childOffsetLength}{element1}{element2}...{childOffset1}{childOffset2}...
-func (node *Node[T]) serialize(maxElements int) []byte {
-	buff := make([]byte, nodSizeByte[T](maxElements))
-
-	startAt := 0
-
-	binary.BigEndian.PutUint64(buff[startAt:startAt+LENGTH_IN_NODE_BYTE], uint64(len(node.elements)))
-	binary.BigEndian.PutUint64(buff[startAt+LENGTH_IN_NODE_BYTE:startAt+LENGTH_IN_NODE_BYTE*2], uint64(len(node.childOffsets)))
-	startAt += metadataSizeByte()
-
-	elementCount := 0
-	for _, element := range node.elements {
-		for _, b := range element.serialize() {
-			buff[startAt+elementCount] = b
-			elementCount += 1
-		}
+// Disk layout: {pageType}{elementLength}{childOffsetLength}{isKeyOnly}{reserved}{prevOffset}{nextOffset}
+// {childOffset1}{childOffset2}...{slot1}{slot2}...{free space}...{element2}{element1}
+// Each slot holds position and length of an element, and elements are packed from the end of page.
+// Internal nodes of B+tree hold key only elements and leaf nodes of B+tree are linked by prevOffset and nextOffset.
+func (node *Node[T]) serialize(pageSize int) []byte {
+	buff := make([]byte, pageSize-CHECKSUM_SIZE_BYTE)
+
+	buff[0] = PAGE_TYPE_NODE
+	binary.BigEndian.PutUint16(buff[1:3], uint16(len(node.elements)))
+	binary.BigEndian.PutUint16(buff[3:5], uint16(len(node.childOffsets)))
+	if node.isKeyOnly() {
+		buff[5] = 1
 	}
-	startAt += totalElementSizeByte[T](maxElements)
+	binary.BigEndian.PutUint64(buff[8:16], uint64(node.prevOffset))
+	binary.BigEndian.PutUint64(buff[16:24], uint64(node.nextOffset))
+	startAt := NODE_HEADER_SIZE_BYTE
 
-	for i, childOffset := range node.childOffsets {
-		binary.BigEndian.PutUint64(buff[startAt+OFFSET_SIZE_BYTE*i:startAt+OFFSET_SIZE_BYTE*(i+1)], uint64(childOffset))
+	for _, childOffset := range node.childOffsets {
+		binary.BigEndian.PutUint64(buff[startAt:startAt+OFFSET_SIZE_BYTE], uint64(childOffset))
+		startAt += OFFSET_SIZE_BYTE
 	}
-	startAt += totalChildOffsetSizeByte[T](maxElements)
 
+	endAt := len(buff)
+	for _, element := range node.elements {
+		elementBuff := element.serialize()
+		endAt -= len(elementBuff)
+		copy(buff[endAt:], elementBuff)
+		binary.BigEndian.PutUint16(buff[startAt:startAt+2], uint16(endAt))
+		binary.BigEndian.PutUint16(buff[startAt+2:startAt+SLOT_SIZE_BYTE], uint16(len(elementBuff)))
+		startAt += SLOT_SIZE_BYTE
+	}
 	return buff
 }
 
-func (node *Node[T]) deserialize(buff []byte, maxElements int) {
-	elementSize := calElementSize[T]()
+func (node *Node[T]) deserialize(buff []byte) {
+	elementLength := int(binary.BigEndian.Uint16(buff[1:3]))
+	childOffsetLength := int(binary.BigEndian.Uint16(buff[3:5]))
+	isKeyOnly := buff[5] == 1
+	node.prevOffset = OffsetType(binary.BigEndian.Uint64(buff[8:16]))
+	node.nextOffset = OffsetType(binary.BigEndian.Uint64(buff[16:24]))
+	startAt := NODE_HEADER_SIZE_BYTE
 
-	startAt := 0
-
-	elementLength := binary.BigEndian.Uint64(buff[startAt : startAt+LENGTH_IN_NODE_BYTE])
-	childOffsetLength := binary.BigEndian.Uint64(buff[startAt+LENGTH_IN_NODE_BYTE : startAt+LENGTH_IN_NODE_BYTE*2])
-	startAt += metadataSizeByte()
+	for i := 0; i < childOffsetLength; i++ {
+		childOffset := OffsetType(binary.BigEndian.Uint64(buff[startAt : startAt+OFFSET_SIZE_BYTE]))
+		node.childOffsets = append(node.childOffsets, childOffset)
+		startAt += OFFSET_SIZE_BYTE
+	}
 
-	for i := 0; i < int(elementLength); i++ {
+	for i := 0; i < elementLength; i++ {
+		position := int(binary.BigEndian.Uint16(buff[startAt : startAt+2]))
+		length := int(binary.BigEndian.Uint16(buff[startAt+2 : startAt+SLOT_SIZE_BYTE]))
 		element := new(Element[T])
-		element.deserialize(buff[startAt+elementSize*i : startAt+elementSize*(i+1)])
+		if isKeyOnly {
+			element.deserializeKey(buff[position : position+length])
+		} else {
+			element.deserialize(buff[position : position+length])
+		}
 		node.elements = append(node.elements, element)
+		startAt += SLOT_SIZE_BYTE
 	}
-	startAt += totalElementSizeByte[T](maxElements)
+}
 
-	for i := 0; i < int(childOffsetLength); i++ {
-		childOffset := OffsetType(binary.BigEndian.Uint64(buff[startAt+OFFSET_SIZE_BYTE*i : startAt+OFFSET_SIZE_BYTE*(i+1)]))
-		node.childOffsets = append(node.childOffsets, childOffset)
+// bodySizeByte returns bytes used by child offsets, slots and elements of the node.
+func (node *Node[T]) bodySizeByte() int {
+	size := OFFSET_SIZE_BYTE * len(node.childOffsets)
+	for _, element := range node.elements {
+		size += SLOT_SIZE_BYTE + element.sizeByte()
 	}
-	startAt += totalChildOffsetSizeByte[T](maxElements)
+	return size
 }
 
 func (node *Node[T]) traverse(key KeyType) (bool, int) {
@@ -102,8 +108,8 @@ func (node *Node[T]) isLeaf() bool {
 	return len(node.childOffsets) == 0
 }
 
-func (node *Node[T]) isOverPopulated(maxElements int) bool {
-	return len(node.elements) > (maxElements - 1)
+func (node *Node[T]) isKeyOnly() bool {
+	return len(node.elements) > 0 && node.elements[0].item == nil
 }
 
 func (node *Node[T]) insertElement(element *Element[T], index int) {
@@ -116,7 +122,7 @@ func (node *Node[T]) insertElement(element *Element[T], index int) {
 }
 
 func (node *Node[T]) insertChildOffset(childOffset OffsetType, index int) {
-	if len(node.elements) == index {
+	if len(node.childOffsets) == index {
 		node.childOffsets = append(node.childOffsets, childOffset)
 	} else {
 		node.childOffsets = append(node.childOffsets[:index+1], node.childOffsets[index:]...)
@@ -124,6 +130,35 @@ func (node *Node[T]) insertChildOffset(childOffset OffsetType, index int) {
 	}
 }
 
+func (node *Node[T]) removeElement(index int) *Element[T] {
+	element := node.elements[index]
+	node.elements = append(node.elements[:index], node.elements[index+1:]...)
+	return element
+}
+
+func (node *Node[T]) removeChildOffset(index int) OffsetType {
+	childOffset := node.childOffsets[index]
+	node.childOffsets = append(node.childOffsets[:index], node.childOffsets[index+1:]...)
+	return childOffset
+}
+
+// merge moves the separator in parentNode and all contents of rightNode into node
+func (node *Node[T]) merge(rightNode *Node[T], parentNode *Node[T], separatorIndex int) {
+	node.elements = append(node.elements, parentNode.removeElement(separatorIndex))
+	node.elements = append(node.elements, rightNode.elements...)
+	node.childOffsets = append(node.childOffsets, rightNode.childOffsets...)
+	parentNode.removeChildOffset(separatorIndex + 1)
+}
+
+// mergeLeaf moves all elements of rightNode into node and drops the separator in parentNode,
+// since leaf nodes of B+tree already hold every key.
+func (node *Node[T]) mergeLeaf(rightNode *Node[T], parentNode *Node[T], separatorIndex int) {
+	node.elements = append(node.elements, rightNode.elements...)
+	node.nextOffset = rightNode.nextOffset
+	parentNode.removeElement(separatorIndex)
+	parentNode.removeChildOffset(separatorIndex + 1)
+}
+
 func (node *Node[T]) print(offset OffsetType, isRoot bool) {
 	ItemKeys := []string{}
 	childOffsets := []string{}
@@ -1,13 +1,13 @@
 package btree
 
 import (
+	"fmt"
 	"testing"
 )
 
 func TestNode(t *testing.T) {
 	t.Run("Test serialize and deserialize", func(t *testing.T) {
 		node := new(Node[Sample])
-		maxItems := 10
 		for i := 0; i < 3; i++ {
 			item := new(Sample)
 			item.Int = i
@@ -17,10 +17,10 @@ func TestNode(t *testing.T) {
 		for i := 0; i < 4; i++ {
 			node.childOffsets = append(node.childOffsets, int64(i))
 		}
-		buff := node.serialize(maxItems)
+		buff := node.serialize(DEFAULT_PAGE_SIZE)
 
 		deserializedNode := new(Node[Sample])
-		deserializedNode.deserialize(buff, maxItems)
+		deserializedNode.deserialize(buff)
 		for i := 0; i < 3; i++ {
 			if deserializedNode.elements[i].item.Int != i {
 				t.Errorf("deserializedNode.items[%d].Int should be %d", i, i)
@@ -44,10 +44,10 @@ func TestNode(t *testing.T) {
 		for i := 0; i < maxItems; i++ {
 			node.childOffsets = append(node.childOffsets, int64(i))
 		}
-		buff := node.serialize(maxItems)
+		buff := node.serialize(DEFAULT_PAGE_SIZE)
 
 		deserializedNode := new(Node[Sample])
-		deserializedNode.deserialize(buff, maxItems)
+		deserializedNode.deserialize(buff)
 		for i := 0; i < maxItems-1; i++ {
 			if deserializedNode.elements[i].item.Int != i {
 				t.Errorf("deserializedNode.items[%d].Int should be %d", i, i)
@@ -59,4 +59,57 @@ func TestNode(t *testing.T) {
 			}
 		}
 	})
+	t.Run("Test serialize and deserialize key only elements and links", func(t *testing.T) {
+		node := new(Node[Sample])
+		node.elements = []*Element[Sample]{newKeyElement[Sample](10), newKeyElement[Sample](20)}
+		node.childOffsets = []OffsetType{100, 200, 300}
+		node.prevOffset = 400
+		node.nextOffset = 500
+		buff := node.serialize(DEFAULT_PAGE_SIZE)
+
+		deserializedNode := new(Node[Sample])
+		deserializedNode.deserialize(buff)
+		if !deserializedNode.isKeyOnly() || deserializedNode.elements[0].getKey() != 10 || deserializedNode.elements[1].getKey() != 20 {
+			t.Errorf("Key only elements should be deserialized")
+		}
+		if deserializedNode.prevOffset != 400 || deserializedNode.nextOffset != 500 {
+			t.Errorf("Links should be deserialized")
+		}
+	})
+	t.Run("Test merge", func(t *testing.T) {
+		newNodeWithKeys := func(keys ...int) *Node[Sample] {
+			node := new(Node[Sample])
+			for _, key := range keys {
+				node.elements = append(node.elements, newElement(&Sample{Int: key}))
+			}
+			return node
+		}
+		keysOf := func(node *Node[Sample]) []int {
+			keys := []int{}
+			for _, element := range node.elements {
+				keys = append(keys, int(element.getKey()))
+			}
+			return keys
+		}
+
+		node := newNodeWithKeys(4, 5)
+		rightNode := newNodeWithKeys(7, 8)
+		parentNode := newNodeWithKeys(3, 6)
+		parentNode.childOffsets = []OffsetType{10, 20, 30}
+
+		node.merge(rightNode, parentNode, 1)
+		if fmt.Sprint(keysOf(node), keysOf(parentNode), parentNode.childOffsets) != "[4 5 6 7 8] [3] [10 20]" {
+			t.Errorf("Right node and separator should be merged into node")
+		}
+	})
+	t.Run("Test mergeLeaf", func(t *testing.T) {
+		node := &Node[Sample]{elements: []*Element[Sample]{newElement(&Sample{Int: 4})}, nextOffset: 20}
+		rightNode := &Node[Sample]{elements: []*Element[Sample]{newElement(&Sample{Int: 6})}, nextOffset: 30}
+		parentNode := &Node[Sample]{elements: []*Element[Sample]{newKeyElement[Sample](6)}, childOffsets: []OffsetType{10, 20}}
+
+		node.mergeLeaf(rightNode, parentNode, 0)
+		if len(node.elements) != 2 || node.nextOffset != 30 || len(parentNode.elements) != 0 || fmt.Sprint(parentNode.childOffsets) != "[10]" {
+			t.Errorf("Right node should be merged into node without separator")
+		}
+	})
 }
@@ -0,0 +1,122 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	pageSize     int
+	layout       int
+	journal      int
+	isDirect     bool
+	lockTimeout  time.Duration
+	syncPolicy   int
+	syncInterval time.Duration
+}
+
+// WithPageSize sets the size of every page in a new data file. When an existing file is opened,
+// it should be omitted or match the page size the file was created with, otherwise IncompatibleFileError is returned.
+func WithPageSize(pageSize int) Option {
+	return func(options *options) {
+		options.pageSize = pageSize
+	}
+}
+
+// WithBPlusTree stores items only in leaf nodes, which are linked to their siblings, and only keys in internal nodes.
+func WithBPlusTree() Option {
+	return func(options *options) {
+		options.layout = LAYOUT_BPLUS_TREE
+	}
+}
+
+// WithCopyOnWrite never overwrites pages of the last committed tree and switches to modified pages
+// by writing the header into one of two alternating slots, instead of logging pages to the WAL.
+func WithCopyOnWrite() Option {
+	return func(options *options) {
+		options.journal = JOURNAL_COPY_ON_WRITE
+	}
+}
+
+// WithLockTimeout makes New wait up to timeout for another tree to release the lock of the data file
+// before returning ErrLocked. New does not wait by default.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(options *options) {
+		options.lockTimeout = timeout
+	}
+}
+
+// WithSync sets when data written by operations is synced to the disk.
+//   - SYNC_OPERATION syncs once per operation, which is the default.
+//   - SYNC_WRITE also syncs after every page written to the data file.
+//   - SYNC_INTERVAL syncs the data file every DEFAULT_SYNC_INTERVAL in background. Each operation still syncs the WAL,
+//     or its pages before the header in copy-on-write mode, where the last operation may be lost on power failure.
+//   - SYNC_NEVER leaves it to the OS, so the data file may be corrupted on power failure.
+//
+// Every policy is safe against crashes of the process. Concurrent Puts and Deletes which arrive while the previous
+// ones are committed share a sync, including those which split or merge nodes or run in copy-on-write mode.
+func WithSync(policy int) Option {
+	return func(options *options) {
+		options.syncPolicy = policy
+	}
+}
+
+// WithSyncInterval syncs every interval in background, like WithSync(SYNC_INTERVAL).
+func WithSyncInterval(interval time.Duration) Option {
+	return func(options *options) {
+		options.syncPolicy = SYNC_INTERVAL
+		options.syncInterval = interval
+	}
+}
+
+// withDirectWrites writes pages to the data file immediately without WAL or copy-on-write,
+// which is used for files that are discarded on failure.
+func withDirectWrites() Option {
+	return func(options *options) {
+		options.isDirect = true
+	}
+}
+
+func newOptions(opts ...Option) (*options, error) {
+	options := new(options)
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.pageSize != 0 && !isValidPageSizeValue(options.pageSize) {
+		return nil, errors.New(fmt.Sprintf("Parameter 'pageSize' should be a power of 2 between %d and %d", MIN_PAGE_SIZE, MAX_PAGE_SIZE))
+	}
+	if options.syncPolicy < SYNC_OPERATION || options.syncPolicy > SYNC_NEVER {
+		return nil, errors.New("Parameter 'policy' should be one of SYNC_OPERATION, SYNC_WRITE, SYNC_INTERVAL and SYNC_NEVER")
+	}
+	if options.syncPolicy == SYNC_INTERVAL && options.syncInterval == 0 {
+		options.syncInterval = DEFAULT_SYNC_INTERVAL
+	}
+	if options.syncInterval < 0 {
+		return nil, errors.New("Parameter 'interval' should be positive")
+	}
+	if options.journal == JOURNAL_COPY_ON_WRITE && options.layout == LAYOUT_BPLUS_TREE {
+		// Every copied leaf would also require copying its siblings to update their links
+		return nil, errors.New("Copy-on-write mode can not be used with B+tree")
+	}
+	return options, nil
+}
+
+// fileOptions returns options to create a data file in the same format as btree.
+func (btree *BTree[T]) fileOptions() []Option {
+	opts := []Option{WithPageSize(btree.pageSize)}
+	if btree.isBPlusTree() {
+		opts = append(opts, WithBPlusTree())
+	}
+	if btree.isCopyOnWrite() {
+		opts = append(opts, WithCopyOnWrite())
+	}
+	return opts
+}
+
+// Page size is limited to MAX_PAGE_SIZE since positions in a page are stored as uint16.
+func isValidPageSizeValue(pageSize int) bool {
+	return pageSize >= MIN_PAGE_SIZE && pageSize <= MAX_PAGE_SIZE && pageSize&(pageSize-1) == 0
+}
@@ -0,0 +1,79 @@
+package btree
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestOptions(t *testing.T) {
+	t.Run("Invalid page size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		for _, pageSize := range []int{-4096, 256, 5000, MAX_PAGE_SIZE * 2} {
+			if _, err := New[Sample](path, DEFAULT_DEGREE, WithPageSize(pageSize)); err == nil {
+				t.Errorf("Error should be raised for page size %d", pageSize)
+			}
+		}
+		if _, err := New[Sample](path, 64, WithPageSize(MIN_PAGE_SIZE)); err == nil {
+			t.Errorf("Error should be raised when nodes do not fit in page")
+		}
+	})
+	t.Run("Nodes are aligned to page size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+		pageSize := 16384
+
+		btree, err := New[Sample](path, PAGE_DEGREE, WithPageSize(pageSize))
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 0; i < 2000; i++ {
+			btree.Put(&Sample{Int: i, String: "value"})
+		}
+		btree.Close()
+
+		btree, err = New[Sample](path, PAGE_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		if btree.pageSize != pageSize {
+			t.Errorf("Page size of existing file should be used")
+		}
+		if btree.getLastOffset()%OffsetType(pageSize) != 0 {
+			t.Errorf("File size should be multiple of page size")
+		}
+		var checkNode func(offset OffsetType)
+		checkNode = func(offset OffsetType) {
+			if offset%OffsetType(pageSize) != 0 {
+				t.Errorf("Node at %d should be aligned to page size", offset)
+			}
+			node, _ := btree.readNodeFromDisk(offset)
+			if offset != btree.getRootOffset() && len(node.elements) < 100 {
+				t.Errorf("Node should hold as many elements as fit in page")
+			}
+			for _, childOffset := range node.childOffsets {
+				checkNode(childOffset)
+			}
+		}
+		checkNode(btree.getRootOffset())
+		for i := 0; i < 2000; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || item.Int != i {
+				t.Errorf("Item %d should be found", i)
+			}
+		}
+	})
+	t.Run("Existing file with different page size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, PAGE_DEGREE, WithPageSize(8192))
+		btree.Close()
+
+		var incompatibleFileError *IncompatibleFileError
+		_, err := New[Sample](path, PAGE_DEGREE, WithPageSize(4096))
+		if !errors.As(err, &incompatibleFileError) || incompatibleFileError.Field != "page size" {
+			t.Errorf("IncompatibleFileError for page size should be raised")
+		}
+	})
+}
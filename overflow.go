@@ -0,0 +1,190 @@
+package btree
+
+import (
+	"encoding/binary"
+	"reflect"
+)
+
+// Values of string and byte slice fields longer than INLINE_MAX_LENGTH are stored out of line
+// in a chain of overflow pages, and are read only when the item is returned by Get.
+// Disk layout of overflow page: {pageType}{nextOverflowOffset}{ownerKey}{field}{dataLength}{reserved}{data}{checksum}
+// Owner key and field allow moving the page without scanning the tree.
+
+type overflow struct {
+	field  int
+	length int
+	offset OffsetType
+}
+
+type overflowPage struct {
+	nextOffset OffsetType
+	ownerKey   KeyType
+	field      int
+	data       []byte
+}
+
+func findOverflow(overflows []*overflow, field int) *overflow {
+	for _, overflow := range overflows {
+		if overflow.field == field {
+			return overflow
+		}
+	}
+	return nil
+}
+
+// spillOverflows writes long values of element into overflow pages.
+func (btree *BTree[T]) spillOverflows(element *Element[T]) error {
+	itemVal := reflect.ValueOf(element.item).Elem()
+	for i := 0; i < itemVal.NumField(); i++ {
+		field := itemVal.FieldByIndex([]int{i})
+		if !field.CanSet() || !isVariableLength(field.Type()) {
+			continue
+		}
+		value := getVariableLengthValue(field)
+		if len(value) <= INLINE_MAX_LENGTH {
+			continue
+		}
+		offset, err := btree.writeOverflowToDisk(element.getKey(), i, value)
+		if err != nil {
+			return err
+		}
+		element.overflows = append(element.overflows, &overflow{field: i, length: len(value), offset: offset})
+	}
+	return nil
+}
+
+// loadOverflows reads values of element stored in overflow pages into its item.
+func (btree *BTree[T]) loadOverflows(element *Element[T]) error {
+	itemVal := reflect.ValueOf(element.item).Elem()
+	for _, overflow := range element.overflows {
+		value := make([]byte, 0, overflow.length)
+		for offset := overflow.offset; offset != 0; {
+			page, err := btree.readOverflowPageFromDisk(offset)
+			if err != nil {
+				return err
+			}
+			value = append(value, page.data...)
+			offset = page.nextOffset
+		}
+		if len(value) != overflow.length {
+			return &CorruptedError{Offset: overflow.offset}
+		}
+		setVariableLengthValue(itemVal.FieldByIndex([]int{overflow.field}), value)
+	}
+	return nil
+}
+
+// freeOverflows returns all overflow pages of element to the free list.
+func (btree *BTree[T]) freeOverflows(element *Element[T]) error {
+	for _, overflow := range element.overflows {
+		for offset := overflow.offset; offset != 0; {
+			page, err := btree.readOverflowPageFromDisk(offset)
+			if err != nil {
+				return err
+			}
+			if err = btree.free(offset); err != nil {
+				return err
+			}
+			offset = page.nextOffset
+		}
+	}
+	return nil
+}
+
+// moveOverflowPage copies the overflow page at offset into a free page and points its owner
+// or the previous page in the chain to the new page.
+func (btree *BTree[T]) moveOverflowPage(offset OffsetType) error {
+	page, err := btree.readOverflowPageFromDisk(offset)
+	if err != nil {
+		return err
+	}
+	isFound, traversedNodes, traversedIndices, err := btree.traverse(page.ownerKey)
+	if err != nil {
+		return err
+	}
+	if !isFound {
+		return &CorruptedError{Offset: offset}
+	}
+	node := traversedNodes[len(traversedNodes)-1]
+	overflow := findOverflow(node.elements[traversedIndices[len(traversedNodes)-1]].overflows, page.field)
+	if overflow == nil {
+		return &CorruptedError{Offset: offset}
+	}
+
+	newOffset, err := btree.allocate()
+	if err != nil {
+		return err
+	}
+	if err = btree.writeOverflowPageToDisk(newOffset, page); err != nil {
+		return err
+	}
+	if overflow.offset == offset {
+		overflow.offset = newOffset
+		return btree.writeNodeToDisk(node)
+	}
+	for previousOffset := overflow.offset; previousOffset != 0; {
+		previousPage, err := btree.readOverflowPageFromDisk(previousOffset)
+		if err != nil {
+			return err
+		}
+		if previousPage.nextOffset == offset {
+			previousPage.nextOffset = newOffset
+			return btree.writeOverflowPageToDisk(previousOffset, previousPage)
+		}
+		previousOffset = previousPage.nextOffset
+	}
+	return &CorruptedError{Offset: offset}
+}
+
+func (btree *BTree[T]) overflowCapacity() int {
+	return btree.pageSize - OVERFLOW_HEADER_SIZE_BYTE - CHECKSUM_SIZE_BYTE
+}
+
+func (btree *BTree[T]) writeOverflowToDisk(ownerKey KeyType, field int, value []byte) (OffsetType, error) {
+	offsets := []OffsetType{}
+	for i := 0; i < len(value); i += btree.overflowCapacity() {
+		offset, err := btree.allocate()
+		if err != nil {
+			return 0, err
+		}
+		offsets = append(offsets, offset)
+	}
+	for i, offset := range offsets {
+		page := &overflowPage{ownerKey: ownerKey, field: field}
+		if i < len(offsets)-1 {
+			page.nextOffset = offsets[i+1]
+			page.data = value[i*btree.overflowCapacity() : (i+1)*btree.overflowCapacity()]
+		} else {
+			page.data = value[i*btree.overflowCapacity():]
+		}
+		if err := btree.writeOverflowPageToDisk(offset, page); err != nil {
+			return 0, err
+		}
+	}
+	return offsets[0], nil
+}
+
+func (btree *BTree[T]) readOverflowPageFromDisk(offset OffsetType) (*overflowPage, error) {
+	buff := make([]byte, btree.pageSize)
+	if err := btree.readAt(buff, offset); err != nil || !verifyChecksum(buff) || buff[0] != PAGE_TYPE_OVERFLOW {
+		return nil, &CorruptedError{Offset: offset}
+	}
+	page := new(overflowPage)
+	page.nextOffset = OffsetType(binary.BigEndian.Uint64(buff[1:9]))
+	page.ownerKey = KeyType(binary.BigEndian.Uint64(buff[9:17]))
+	page.field = int(binary.BigEndian.Uint16(buff[17:19]))
+	dataLength := int(binary.BigEndian.Uint16(buff[19:21]))
+	page.data = buff[OVERFLOW_HEADER_SIZE_BYTE : OVERFLOW_HEADER_SIZE_BYTE+dataLength]
+	return page, nil
+}
+
+func (btree *BTree[T]) writeOverflowPageToDisk(offset OffsetType, page *overflowPage) error {
+	buff := make([]byte, btree.pageSize-CHECKSUM_SIZE_BYTE)
+	buff[0] = PAGE_TYPE_OVERFLOW
+	binary.BigEndian.PutUint64(buff[1:9], uint64(page.nextOffset))
+	binary.BigEndian.PutUint64(buff[9:17], uint64(page.ownerKey))
+	binary.BigEndian.PutUint16(buff[17:19], uint16(page.field))
+	binary.BigEndian.PutUint16(buff[19:21], uint16(len(page.data)))
+	copy(buff[OVERFLOW_HEADER_SIZE_BYTE:], page.data)
+	return btree.writeAt(appendChecksum(buff), offset)
+}
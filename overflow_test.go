@@ -0,0 +1,151 @@
+package btree
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type Document struct {
+	ID    int
+	Title string `maxLength:"32"`
+	Body  string
+	Data  []byte
+}
+
+func (item Document) GetKey() int64 {
+	return int64(item.ID)
+}
+
+func newDocument(id int, length int) *Document {
+	return &Document{
+		ID:    id,
+		Title: "document",
+		Body:  strings.Repeat(string(rune('a'+id%26)), length),
+		Data:  bytes.Repeat([]byte{byte(id)}, length/2),
+	}
+}
+
+func isSameDocument(a *Document, b *Document) bool {
+	return a.ID == b.ID && a.Title == b.Title && a.Body == b.Body && bytes.Equal(a.Data, b.Data)
+}
+
+func TestOverflow(t *testing.T) {
+	t.Run("Put -> Get large values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, err := New[Document](path, PAGE_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 0; i < 50; i++ {
+			if err = btree.Put(newDocument(i, i*500)); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		btree.Close()
+
+		btree, err = New[Document](path, PAGE_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+		for i := 0; i < 50; i++ {
+			item, err := btree.Get(KeyType(i))
+			if err != nil || !isSameDocument(item, newDocument(i, i*500)) {
+				t.Errorf("Document %d should be restored", i)
+			}
+		}
+	})
+	t.Run("Values in overflow pages are read lazily", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, PAGE_DEGREE)
+		defer btree.Close()
+
+		btree.Put(newDocument(1, 10000))
+		btree.Put(newDocument(2, 100))
+
+		_, traversedNodes, traversedIndices, _ := btree.traverse(1)
+		element := traversedNodes[len(traversedNodes)-1].elements[traversedIndices[len(traversedNodes)-1]]
+		if element.item.Body != "" || element.item.Data != nil || len(element.overflows) != 2 {
+			t.Errorf("Large values should not be read while traversing")
+		}
+		if err := btree.loadOverflows(element); err != nil || !isSameDocument(element.item, newDocument(1, 10000)) {
+			t.Errorf("Large values should be read by loadOverflows")
+		}
+
+		_, traversedNodes, traversedIndices, _ = btree.traverse(2)
+		element = traversedNodes[len(traversedNodes)-1].elements[traversedIndices[len(traversedNodes)-1]]
+		if len(element.overflows) != 0 || !isSameDocument(element.item, newDocument(2, 100)) {
+			t.Errorf("Short values should be stored inline")
+		}
+	})
+	t.Run("Overflow pages are freed on update and delete", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, PAGE_DEGREE)
+		defer btree.Close()
+
+		fileSize := int64(0)
+		for round := 0; round < 3; round++ {
+			for i := 0; i < 20; i++ {
+				btree.Put(newDocument(i, 20000))
+			}
+			for i := 0; i < 10; i++ {
+				btree.Put(newDocument(i, 10))
+			}
+			for i := 10; i < 20; i++ {
+				btree.Delete(KeyType(i))
+			}
+			file, _ := os.Stat(path)
+			if round > 0 && file.Size() > fileSize {
+				t.Errorf("File size should not grow after round %d", round)
+			}
+			fileSize = file.Size()
+		}
+		for i := 0; i < 10; i++ {
+			if item, err := btree.Get(KeyType(i)); err != nil || !isSameDocument(item, newDocument(i, 10)) {
+				t.Errorf("Document %d should be updated", i)
+			}
+		}
+	})
+	t.Run("Compact and IncrementalVacuum keep large values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, PAGE_DEGREE)
+		defer btree.Close()
+
+		for i := 0; i < 40; i++ {
+			btree.Put(newDocument(i, 8000))
+		}
+		for i := 0; i < 40; i += 2 {
+			btree.Delete(KeyType(i))
+		}
+		for {
+			count, err := btree.IncrementalVacuum(10)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			if count == 0 {
+				break
+			}
+		}
+		for i := 1; i < 40; i += 2 {
+			if item, err := btree.Get(KeyType(i)); err != nil || !isSameDocument(item, newDocument(i, 8000)) {
+				t.Errorf("Document %d should be kept by IncrementalVacuum", i)
+			}
+		}
+
+		if err := btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 1; i < 40; i += 2 {
+			if item, err := btree.Get(KeyType(i)); err != nil || !isSameDocument(item, newDocument(i, 8000)) {
+				t.Errorf("Document %d should be kept by Compact", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// OpenReadOnly opens an existing data file without modifying it, so that it can be shared by many processes.
+// Degree, page size, B+tree layout and journal mode are read from the header, so only WithLockTimeout takes effect.
+// The file is opened with a shared lock, which conflicts only with trees opened by New.
+func OpenReadOnly[T Item](path string, opts ...Option) (*BTree[T], error) {
+	if err := isValidItemFields[T](); err != nil {
+		return nil, err
+	}
+	if err := isValidStringLabel[T](); err != nil {
+		return nil, err
+	}
+	options, err := newOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := os.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to open data file at %s", path))
+	}
+	if err = lockFile(fp, true, options.lockTimeout); err != nil {
+		fp.Close()
+		return nil, err
+	}
+
+	btree := new(BTree[T])
+	btree.path = path
+	btree.isReadOnly = true
+	btree.snapshots = map[*Snapshot[T]]bool{}
+	btree.latches = newLatchTable()
+	btree.fp = fp
+	btree.isOpen = true
+
+	if err = btree.checkWAL(); err != nil {
+		btree.close()
+		return nil, err
+	}
+	header, err := btree.readHeaderFromDisk()
+	if err == nil {
+		btree.degree = int(header.degree)
+		btree.pageSize = int(header.pageSize)
+		if !isValidPageSizeValue(btree.pageSize) {
+			btree.pageSize = DEFAULT_PAGE_SIZE
+		}
+		btree.layout = int(header.layout)
+		btree.journal = int(header.journal)
+		err = header.validate(newHeader[T](btree.degree, btree.pageSize, btree.layout, btree.journal))
+	}
+	if err == nil {
+		err = btree.isValidPageSize()
+	}
+	if err != nil {
+		btree.close()
+		return nil, err
+	}
+	btree.header = header
+	btree.endOffset = btree.getLastOffset()
+	btree.committedEndOffset = btree.endOffset
+	return btree, nil
+}
+
+// checkWAL returns an error if the WAL has an operation which should be replayed by New before the file is read.
+func (btree *BTree[T]) checkWAL() error {
+	fp, err := os.Open(btree.path + WAL_PATH_SUFFIX)
+	if err != nil {
+		// The WAL does not exist when the file is copied without it
+		return nil
+	}
+	wal := new(wal)
+	wal.fp = fp
+	defer wal.close()
+	_, _, isCommitted, err := wal.read()
+	if err != nil {
+		return err
+	}
+	if isCommitted {
+		return errors.New("WAL has an operation to be replayed, so data file should be opened by New first")
+	}
+	return nil
+}
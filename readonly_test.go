@@ -0,0 +1,116 @@
+package btree
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenReadOnly(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           {WithPageSize(8192)},
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run("Get from read-only trees with "+name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, PAGE_DEGREE, opts...)
+			for i := 0; i < 300; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+			btree.Close()
+			data, _ := os.ReadFile(path)
+
+			first, err := OpenReadOnly[Sample](path)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			second, err := OpenReadOnly[Sample](path)
+			if err != nil {
+				t.Fatalf("Read-only trees should be opened simultaneously")
+			}
+			for _, readOnly := range []*BTree[Sample]{first, second} {
+				for i := 0; i < 300; i++ {
+					if item, err := readOnly.Get(KeyType(i)); err != nil || item.Int != i {
+						t.Errorf("Item should be found")
+					}
+				}
+			}
+			if _, err = New[Sample](path, PAGE_DEGREE, opts...); !errors.Is(err, ErrLocked) {
+				t.Errorf("ErrLocked should be raised")
+			}
+
+			var readOnlyError *ReadOnlyError
+			if err = first.Put(&Sample{Int: 300}); !errors.As(err, &readOnlyError) || readOnlyError.Operation != "Put" {
+				t.Errorf("ReadOnlyError should be raised")
+			}
+			if err = first.Delete(0); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("ErrReadOnly should be raised")
+			}
+			if _, err = first.Begin(); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("ErrReadOnly should be raised")
+			}
+			if err = first.Compact(1); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("ErrReadOnly should be raised")
+			}
+			if _, err = first.IncrementalVacuum(1); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("ErrReadOnly should be raised")
+			}
+			first.Close()
+			second.Close()
+
+			if after, _ := os.ReadFile(path); !bytes.Equal(data, after) {
+				t.Errorf("Data file should not be modified")
+			}
+		})
+	}
+	t.Run("Read-only tree can not be opened while tree is open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		if _, err := OpenReadOnly[Sample](path); !errors.Is(err, ErrLocked) {
+			t.Errorf("ErrLocked should be raised")
+		}
+		btree.Close()
+	})
+	t.Run("Missing or incompatible file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		if _, err := OpenReadOnly[Sample](path); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Data file should not be created")
+		}
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		btree.Close()
+		if _, err := OpenReadOnly[Other](path); !errors.Is(err, ErrIncompatibleFile) {
+			t.Errorf("ErrIncompatibleFile should be raised")
+		}
+	})
+	t.Run("WAL to be replayed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		crashAfterLogging(t, btree, []int{1, 2, 3})
+		if _, err := OpenReadOnly[Sample](path); err == nil {
+			t.Errorf("Error should be raised")
+		}
+
+		btree, _ = New[Sample](path, DEFAULT_DEGREE)
+		btree.Close()
+		readOnly, err := OpenReadOnly[Sample](path)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer readOnly.Close()
+		if _, err = readOnly.Get(2); err != nil {
+			t.Errorf("Replayed item should be found")
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package btree
+
+import "errors"
+
+// Ascend calls fn for every item in ascending order of keys until fn returns false.
+// The tree is locked for reading during the scan, so fn should not modify it.
+func (btree *BTree[T]) Ascend(fn func(item *T) bool) error {
+	return btree.scan(func(tree *BTree[T]) error {
+		return tree.ascendRange(nil, nil, fn)
+	})
+}
+
+// AscendRange calls fn for items with keys in [greaterOrEqual, lessThan) in ascending order until fn returns false.
+func (btree *BTree[T]) AscendRange(greaterOrEqual KeyType, lessThan KeyType, fn func(item *T) bool) error {
+	return btree.scan(func(tree *BTree[T]) error {
+		return tree.ascendRange(&greaterOrEqual, &lessThan, fn)
+	})
+}
+
+// Descend calls fn for every item in descending order of keys until fn returns false.
+func (btree *BTree[T]) Descend(fn func(item *T) bool) error {
+	return btree.scan(func(tree *BTree[T]) error {
+		return tree.descendRange(nil, nil, fn)
+	})
+}
+
+// DescendRange calls fn for items with keys in (greaterThan, lessOrEqual] in descending order until fn returns false.
+func (btree *BTree[T]) DescendRange(lessOrEqual KeyType, greaterThan KeyType, fn func(item *T) bool) error {
+	return btree.scan(func(tree *BTree[T]) error {
+		return tree.descendRange(&lessOrEqual, &greaterThan, fn)
+	})
+}
+
+func (snapshot *Snapshot[T]) Ascend(fn func(item *T) bool) error {
+	return snapshot.scan(func(tree *BTree[T]) error {
+		return tree.ascendRange(nil, nil, fn)
+	})
+}
+
+func (snapshot *Snapshot[T]) AscendRange(greaterOrEqual KeyType, lessThan KeyType, fn func(item *T) bool) error {
+	return snapshot.scan(func(tree *BTree[T]) error {
+		return tree.ascendRange(&greaterOrEqual, &lessThan, fn)
+	})
+}
+
+func (snapshot *Snapshot[T]) Descend(fn func(item *T) bool) error {
+	return snapshot.scan(func(tree *BTree[T]) error {
+		return tree.descendRange(nil, nil, fn)
+	})
+}
+
+func (snapshot *Snapshot[T]) DescendRange(lessOrEqual KeyType, greaterThan KeyType, fn func(item *T) bool) error {
+	return snapshot.scan(func(tree *BTree[T]) error {
+		return tree.descendRange(&lessOrEqual, &greaterThan, fn)
+	})
+}
+
+func (btree *BTree[T]) scan(fn func(tree *BTree[T]) error) error {
+	btree.lock.RLock()
+	defer btree.lock.RUnlock()
+	if !btree.isOpen {
+		return errors.New("Tree is closed")
+	}
+	return fn(btree)
+}
+
+func (snapshot *Snapshot[T]) scan(fn func(tree *BTree[T]) error) error {
+	snapshot.btree.lock.RLock()
+	defer snapshot.btree.lock.RUnlock()
+	if snapshot.isClosed {
+		return errors.New("Snapshot is closed")
+	}
+	return fn(snapshot.tree)
+}
+
+// ascendRange visits items with keys in [lo, hi), where nil means no bound. Leaves of B+tree are followed
+// by their links from the leaf which holds lo without walking internal nodes.
+func (btree *BTree[T]) ascendRange(lo *KeyType, hi *KeyType, fn func(item *T) bool) error {
+	if !btree.isBPlusTree() {
+		_, err := btree.ascend(btree.getRootOffset(), lo, hi, fn)
+		return err
+	}
+	var node *Node[T]
+	index := 0
+	if lo != nil {
+		_, traversedNodes, traversedIndices, err := btree.traverse(*lo)
+		if err != nil {
+			return err
+		}
+		node = traversedNodes[len(traversedNodes)-1]
+		index = traversedIndices[len(traversedIndices)-1]
+	} else {
+		var err error
+		if node, err = btree.readNodeFromDisk(btree.getRootOffset()); err != nil {
+			return err
+		}
+		for !node.isLeaf() {
+			if node, err = btree.readNodeFromDisk(node.childOffsets[0]); err != nil {
+				return err
+			}
+		}
+	}
+	for {
+		for ; index < len(node.elements); index++ {
+			if hi != nil && node.elements[index].getKey() >= *hi {
+				return nil
+			}
+			if isContinued, err := btree.visit(node.elements[index], fn); err != nil || !isContinued {
+				return err
+			}
+		}
+		if node.nextOffset == 0 {
+			return nil
+		}
+		var err error
+		if node, err = btree.readNodeFromDisk(node.nextOffset); err != nil {
+			return err
+		}
+		index = 0
+	}
+}
+
+// descendRange visits items with keys in (lo, hi] in descending order, following previous links of leaves of B+tree.
+func (btree *BTree[T]) descendRange(hi *KeyType, lo *KeyType, fn func(item *T) bool) error {
+	if !btree.isBPlusTree() {
+		_, err := btree.descend(btree.getRootOffset(), hi, lo, fn)
+		return err
+	}
+	var node *Node[T]
+	var index int
+	if hi != nil {
+		isFound, traversedNodes, traversedIndices, err := btree.traverse(*hi)
+		if err != nil {
+			return err
+		}
+		node = traversedNodes[len(traversedNodes)-1]
+		index = traversedIndices[len(traversedIndices)-1]
+		if !isFound {
+			// Index points to the first element greater than hi
+			index -= 1
+		}
+	} else {
+		var err error
+		if node, err = btree.readNodeFromDisk(btree.getRootOffset()); err != nil {
+			return err
+		}
+		for !node.isLeaf() {
+			if node, err = btree.readNodeFromDisk(node.childOffsets[len(node.childOffsets)-1]); err != nil {
+				return err
+			}
+		}
+		index = len(node.elements) - 1
+	}
+	for {
+		for ; index >= 0; index-- {
+			if lo != nil && node.elements[index].getKey() <= *lo {
+				return nil
+			}
+			if isContinued, err := btree.visit(node.elements[index], fn); err != nil || !isContinued {
+				return err
+			}
+		}
+		if node.prevOffset == 0 {
+			return nil
+		}
+		var err error
+		if node, err = btree.readNodeFromDisk(node.prevOffset); err != nil {
+			return err
+		}
+		index = len(node.elements) - 1
+	}
+}
+
+// ascend visits items with keys in [lo, hi) under the node at offset, where nil means no bound,
+// and returns false when fn stops the scan. Children which can not hold keys in the range are not read.
+func (btree *BTree[T]) ascend(offset OffsetType, lo *KeyType, hi *KeyType, fn func(item *T) bool) (bool, error) {
+	node, err := btree.readNodeFromDisk(offset)
+	if err != nil {
+		return false, err
+	}
+	for i, element := range node.elements {
+		key := element.getKey()
+		// Keys under the child before an element are less than its key in both B-tree and B+tree
+		if !node.isLeaf() && (lo == nil || *lo < key) {
+			if isContinued, err := btree.ascend(node.childOffsets[i], lo, hi, fn); err != nil || !isContinued {
+				return isContinued, err
+			}
+		}
+		if hi != nil && key >= *hi {
+			return true, nil
+		}
+		if lo == nil || key >= *lo {
+			if isContinued, err := btree.visit(element, fn); err != nil || !isContinued {
+				return isContinued, err
+			}
+		}
+	}
+	if !node.isLeaf() {
+		return btree.ascend(node.childOffsets[len(node.childOffsets)-1], lo, hi, fn)
+	}
+	return true, nil
+}
+
+// descend visits items with keys in (lo, hi] under the node at offset in descending order.
+func (btree *BTree[T]) descend(offset OffsetType, hi *KeyType, lo *KeyType, fn func(item *T) bool) (bool, error) {
+	node, err := btree.readNodeFromDisk(offset)
+	if err != nil {
+		return false, err
+	}
+	for i := len(node.elements) - 1; i >= 0; i-- {
+		element := node.elements[i]
+		key := element.getKey()
+		// Keys under the child after an element are greater than or equal to its key in both B-tree and B+tree
+		if !node.isLeaf() && (hi == nil || key <= *hi) {
+			if isContinued, err := btree.descend(node.childOffsets[i+1], hi, lo, fn); err != nil || !isContinued {
+				return isContinued, err
+			}
+		}
+		if lo != nil && key <= *lo {
+			return true, nil
+		}
+		if hi == nil || key <= *hi {
+			if isContinued, err := btree.visit(element, fn); err != nil || !isContinued {
+				return isContinued, err
+			}
+		}
+	}
+	if !node.isLeaf() {
+		return btree.descend(node.childOffsets[0], hi, lo, fn)
+	}
+	return true, nil
+}
+
+// visit calls fn for the item of element unless it is closed or a key only element of B+tree.
+func (btree *BTree[T]) visit(element *Element[T], fn func(item *T) bool) (bool, error) {
+	if element.item == nil || element.isClosed {
+		return true, nil
+	}
+	if err := btree.loadOverflows(element); err != nil {
+		return false, err
+	}
+	return fn(element.item), nil
+}
@@ -0,0 +1,199 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		for _, degree := range []int{2, 3, PAGE_DEGREE} {
+			t.Run(fmt.Sprintf("Ascend and Descend with %s and degree %d", name, degree), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+				random := rand.New(rand.NewSource(int64(degree)))
+
+				btree, _ := New[Sample](path, degree, opts...)
+				defer btree.Close()
+				for _, key := range random.Perm(300) {
+					btree.Put(&Sample{Int: key})
+				}
+				isDeleted := map[int]bool{}
+				for _, key := range random.Perm(300)[:100] {
+					btree.Delete(KeyType(key))
+					isDeleted[key] = true
+				}
+				expected := []int{}
+				for key := 0; key < 300; key++ {
+					if !isDeleted[key] {
+						expected = append(expected, key)
+					}
+				}
+
+				collect := func(scan func(fn func(item *Sample) bool) error) []int {
+					keys := []int{}
+					if err := scan(func(item *Sample) bool {
+						keys = append(keys, item.Int)
+						return true
+					}); err != nil {
+						t.Errorf("Error should not be raised")
+					}
+					return keys
+				}
+				filter := func(isIncluded func(key int) bool, isReversed bool) []int {
+					keys := []int{}
+					for _, key := range expected {
+						if isIncluded(key) {
+							keys = append(keys, key)
+						}
+					}
+					if isReversed {
+						for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+							keys[i], keys[j] = keys[j], keys[i]
+						}
+					}
+					return keys
+				}
+
+				if fmt.Sprint(collect(btree.Ascend)) != fmt.Sprint(expected) {
+					t.Errorf("Every item should be visited in ascending order")
+				}
+				if fmt.Sprint(collect(btree.Descend)) != fmt.Sprint(filter(func(key int) bool { return true }, true)) {
+					t.Errorf("Every item should be visited in descending order")
+				}
+				for i := 0; i < 50; i++ {
+					lo, hi := random.Intn(320)-10, random.Intn(320)-10
+					ascended := collect(func(fn func(item *Sample) bool) error {
+						return btree.AscendRange(KeyType(lo), KeyType(hi), fn)
+					})
+					if fmt.Sprint(ascended) != fmt.Sprint(filter(func(key int) bool { return lo <= key && key < hi }, false)) {
+						t.Errorf("Items in [%d, %d) should be visited in ascending order", lo, hi)
+					}
+					descended := collect(func(fn func(item *Sample) bool) error {
+						return btree.DescendRange(KeyType(hi), KeyType(lo), fn)
+					})
+					if fmt.Sprint(descended) != fmt.Sprint(filter(func(key int) bool { return lo < key && key <= hi }, true)) {
+						t.Errorf("Items in (%d, %d] should be visited in descending order", lo, hi)
+					}
+				}
+			})
+		}
+	}
+	t.Run("Leaves of B+tree are scanned by their links", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3, WithBPlusTree())
+		defer btree.Close()
+		for i := 0; i < 300; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		// Internal nodes other than those on the path to the first leaf should not be read
+		rootNode, _ := btree.readNodeFromDisk(btree.getRootOffset())
+		lastOffset := rootNode.childOffsets[len(rootNode.childOffsets)-1]
+		if lastNode, _ := btree.readNodeFromDisk(lastOffset); lastNode.isLeaf() {
+			t.Fatalf("Child of root node should be internal node")
+		}
+		btree.fp.WriteAt(make([]byte, btree.pageSize), lastOffset)
+
+		count := 0
+		err := btree.AscendRange(0, 300, func(item *Sample) bool {
+			count += 1
+			return true
+		})
+		if err != nil || count != 300 {
+			t.Errorf("Every item should be visited through links of leaves")
+		}
+	})
+	t.Run("Stop scan", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 100; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		count := 0
+		btree.AscendRange(10, 90, func(item *Sample) bool {
+			count += 1
+			return item.Int < 20
+		})
+		if count != 11 {
+			t.Errorf("Scan should stop when fn returns false")
+		}
+		count = 0
+		btree.Descend(func(item *Sample) bool {
+			count += 1
+			return count < 5
+		})
+		if count != 5 {
+			t.Errorf("Scan should stop when fn returns false")
+		}
+	})
+	t.Run("Items in overflow pages", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Document](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		for i := 0; i < 20; i++ {
+			btree.Put(newDocument(i, i*100))
+		}
+		btree.Ascend(func(item *Document) bool {
+			if !isSameDocument(item, newDocument(item.ID, item.ID*100)) {
+				t.Errorf("Values in overflow pages should be loaded")
+			}
+			return true
+		})
+	})
+	t.Run("Scan snapshot", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 100; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		snapshot, _ := btree.Snapshot()
+		for i := 0; i < 100; i += 2 {
+			btree.Delete(KeyType(i))
+		}
+		for i := 100; i < 200; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		count := 0
+		snapshot.Ascend(func(item *Sample) bool {
+			if item.Int != count {
+				t.Errorf("Item %d of snapshot should be visited", count)
+			}
+			count += 1
+			return true
+		})
+		if count != 100 {
+			t.Errorf("Every item of snapshot should be visited")
+		}
+		count = 0
+		snapshot.DescendRange(50, 40, func(item *Sample) bool {
+			count += 1
+			return true
+		})
+		if count != 10 {
+			t.Errorf("Items of snapshot in range should be visited")
+		}
+
+		snapshot.Close()
+		if err := snapshot.Ascend(func(item *Sample) bool { return true }); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		btree.Close()
+		if err := btree.Descend(func(item *Sample) bool { return true }); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Snapshot is a read-only view of the tree pinned to the version committed when it was taken.
+// Before a commit overwrites or truncates a page of the data file, the old page is kept in every open snapshot
+// which may read it, and it is released when the snapshot is closed. In copy-on-write mode, committed pages are
+// never overwritten, and pages freed after the snapshot are not reused until it is closed instead.
+type Snapshot[T Item] struct {
+	btree    *BTree[T]
+	tree     *BTree[T]
+	isClosed bool
+}
+
+// Snapshot returns a view of the last committed tree, which does not include changes of a transaction in progress.
+// It should be closed to release pages kept for it.
+func (btree *BTree[T]) Snapshot() (*Snapshot[T], error) {
+	btree.lock.Lock()
+	defer btree.lock.Unlock()
+	if !btree.isOpen {
+		return nil, errors.New("Tree is closed")
+	}
+	// The snapshot has its own file so that it is not affected by Compact which replaces the data file
+	fp, err := os.Open(btree.path)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Failed to open data file at %s", btree.path))
+	}
+	snapshot := new(Snapshot[T])
+	snapshot.btree = btree
+	snapshot.tree = btree.fork()
+	snapshot.tree.fp = fp
+	snapshot.tree.wal = nil
+	snapshot.tree.cleanPages = nil
+	btree.snapshots[snapshot] = true
+	return snapshot, nil
+}
+
+func (snapshot *Snapshot[T]) Get(key KeyType) (*T, error) {
+	// Pages are kept for the snapshot while the tree is locked exclusively
+	snapshot.btree.lock.RLock()
+	defer snapshot.btree.lock.RUnlock()
+	if snapshot.isClosed {
+		return nil, errors.New("Snapshot is closed")
+	}
+	return snapshot.tree.get(key)
+}
+
+func (snapshot *Snapshot[T]) Close() error {
+	snapshot.btree.lock.Lock()
+	defer snapshot.btree.lock.Unlock()
+	if snapshot.isClosed {
+		return errors.New("Snapshot is already closed")
+	}
+	snapshot.isClosed = true
+	delete(snapshot.btree.snapshots, snapshot)
+	snapshot.tree.dirtyPages = nil
+	return snapshot.tree.fp.Close()
+}
+
+// preservePages keeps pages at offsets and pages beyond endOffset in open snapshots before they are
+// overwritten or truncated. Pages kept once are not read again since they hold the version of the snapshot.
+func (btree *BTree[T]) preservePages(offsets []OffsetType, endOffset OffsetType) {
+	for snapshot := range btree.snapshots {
+		view := snapshot.tree
+		targets := append([]OffsetType{}, offsets...)
+		for offset := endOffset; offset < view.endOffset; offset += OffsetType(btree.pageSize) {
+			targets = append(targets, offset)
+		}
+		for _, offset := range targets {
+			if _, ok := view.dirtyPages[offset]; ok || offset >= view.endOffset {
+				continue
+			}
+			buff := make([]byte, btree.pageSize)
+			if _, err := btree.fp.ReadAt(buff, offset); err != nil {
+				continue
+			}
+			view.dirtyPages[offset] = buff
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package btree
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("Put -> Delete -> Vacuum during snapshot with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, 3, opts...)
+			defer btree.Close()
+			for i := 0; i < 200; i++ {
+				btree.Put(&Sample{Int: i, String: "old"})
+			}
+
+			snapshot, err := btree.Snapshot()
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			for i := 0; i < 200; i++ {
+				btree.Put(&Sample{Int: i, String: "new"})
+			}
+			for i := 0; i < 200; i += 2 {
+				btree.Delete(KeyType(i))
+			}
+			for i := 200; i < 300; i++ {
+				btree.Put(&Sample{Int: i, String: "new"})
+			}
+			for i := 200; i < 300; i++ {
+				btree.Delete(KeyType(i))
+			}
+			btree.IncrementalVacuum(1000)
+
+			for i := 0; i < 300; i++ {
+				item, err := snapshot.Get(KeyType(i))
+				if i < 200 && (err != nil || item.String != "old") {
+					t.Errorf("Item should be found as of snapshot")
+				} else if i >= 200 && err == nil {
+					t.Errorf("Item put after snapshot should not be found")
+				}
+			}
+			if name == "copy-on-write" {
+				if len(snapshot.tree.dirtyPages) != 0 || len(btree.heldFrees) == 0 {
+					t.Errorf("Freed pages should be held in the file instead of memory")
+				}
+			} else if len(snapshot.tree.dirtyPages) == 0 {
+				t.Errorf("Overwritten pages should be kept for snapshot")
+			}
+			if err = snapshot.Close(); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			if len(btree.snapshots) != 0 || snapshot.tree.dirtyPages != nil {
+				t.Errorf("Kept pages should be released")
+			}
+			btree.Put(&Sample{Int: 1, String: "new"})
+			if len(btree.heldFrees) != 0 {
+				t.Errorf("Held pages should be freed by the next commit")
+			}
+			if _, err = snapshot.Get(1); err == nil {
+				t.Errorf("Error should be raised")
+			}
+
+			for i := 0; i < 200; i++ {
+				item, err := btree.Get(KeyType(i))
+				if i%2 == 0 && err == nil {
+					t.Errorf("Deleted item should not be found")
+				} else if i%2 == 1 && (err != nil || item.String != "new") {
+					t.Errorf("Item should be updated")
+				}
+			}
+			checkTree(t, btree)
+		})
+	}
+	t.Run("Snapshot does not see transaction", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		btree.Put(&Sample{Int: 1})
+
+		tx, _ := btree.Begin()
+		tx.Put(&Sample{Int: 2})
+		snapshot, _ := btree.Snapshot()
+		defer snapshot.Close()
+		tx.Delete(1)
+		tx.Commit()
+
+		if _, err := snapshot.Get(1); err != nil {
+			t.Errorf("Item deleted after snapshot should be found")
+		}
+		if _, err := snapshot.Get(2); err == nil {
+			t.Errorf("Item of transaction in progress should not be found")
+		}
+	})
+	t.Run("Snapshot survives Compact", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, 3)
+		defer btree.Close()
+		for i := 0; i < 100; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		snapshot, _ := btree.Snapshot()
+		defer snapshot.Close()
+		for i := 0; i < 100; i += 2 {
+			btree.Delete(KeyType(i))
+		}
+		if err := btree.Compact(1); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		for i := 0; i < 100; i++ {
+			btree.Put(&Sample{Int: i + 100})
+		}
+
+		for i := 0; i < 200; i++ {
+			if _, err := snapshot.Get(KeyType(i)); (err == nil) != (i < 100) {
+				t.Errorf("Snapshot should not be affected by Compact")
+			}
+		}
+	})
+}
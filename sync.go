@@ -0,0 +1,83 @@
+package btree
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// file is the part of *os.File used for the data file and the WAL.
+type file interface {
+	io.ReaderAt
+	io.WriterAt
+	Truncate(size int64) error
+	Sync() error
+	Close() error
+	Stat() (os.FileInfo, error)
+}
+
+// writePage writes a committed page to the data file, and syncs it if every write should be synced.
+func (btree *BTree[T]) writePage(buff []byte, offset OffsetType) error {
+	if _, err := btree.fp.WriteAt(buff, offset); err != nil {
+		return err
+	}
+	if btree.syncPolicy == SYNC_WRITE {
+		return btree.fp.Sync()
+	}
+	return nil
+}
+
+// syncFile syncs fp at the end of an operation unless it is left to the background flusher or the OS.
+func (btree *BTree[T]) syncFile(fp file) error {
+	if btree.syncPolicy == SYNC_INTERVAL || btree.syncPolicy == SYNC_NEVER {
+		return nil
+	}
+	return fp.Sync()
+}
+
+// syncBarrier syncs fp before writes which depend on it, such as pages applied after their WAL records or
+// a header referring to new pages. It is required even with SYNC_INTERVAL, since writes may reach the disk
+// in any order until they are synced, and only SYNC_NEVER skips it.
+func (btree *BTree[T]) syncBarrier(fp file) error {
+	if btree.syncPolicy == SYNC_NEVER {
+		return nil
+	}
+	return fp.Sync()
+}
+
+// startFlusher makes a checkpoint every interval until the tree is closed.
+func (btree *BTree[T]) startFlusher(interval time.Duration) {
+	btree.stopFlusher = make(chan struct{})
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-btree.stopFlusher:
+				return
+			case <-ticker.C:
+				btree.flush()
+			}
+		}
+	}()
+}
+
+func (btree *BTree[T]) flush() {
+	btree.lock.RLock()
+	defer btree.lock.RUnlock()
+	if !btree.isOpen {
+		return
+	}
+	btree.checkpoint()
+}
+
+// checkpoint syncs the data file and discards operations in the WAL, which are no longer needed to recover it.
+func (btree *BTree[T]) checkpoint() error {
+	if err := btree.fp.Sync(); err != nil {
+		return err
+	}
+	if btree.wal != nil {
+		return btree.wal.reset()
+	}
+	return nil
+}
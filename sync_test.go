@@ -0,0 +1,241 @@
+package btree
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// crashFile keeps a file in memory together with the writes which are not synced yet,
+// so that a power failure is simulated by applying only some of them in random order.
+type crashFile struct {
+	synced   []byte
+	contents []byte
+	writes   []func(contents []byte) []byte
+}
+
+type crashFileInfo struct {
+	os.FileInfo
+	size int64
+}
+
+func (info crashFileInfo) Size() int64 {
+	return info.size
+}
+
+func newCrashFile(contents []byte) *crashFile {
+	file := new(crashFile)
+	file.synced = append([]byte{}, contents...)
+	file.contents = append([]byte{}, contents...)
+	return file
+}
+
+func (file *crashFile) ReadAt(buff []byte, offset int64) (int, error) {
+	if offset >= int64(len(file.contents)) {
+		return 0, io.EOF
+	}
+	n := copy(buff, file.contents[offset:])
+	if n < len(buff) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (file *crashFile) WriteAt(buff []byte, offset int64) (int, error) {
+	data := append([]byte{}, buff...)
+	file.apply(func(contents []byte) []byte {
+		if end := int(offset) + len(data); end > len(contents) {
+			contents = append(contents, make([]byte, end-len(contents))...)
+		}
+		copy(contents[offset:], data)
+		return contents
+	})
+	return len(buff), nil
+}
+
+func (file *crashFile) Truncate(size int64) error {
+	file.apply(func(contents []byte) []byte {
+		if int(size) <= len(contents) {
+			return contents[:size]
+		}
+		return append(contents, make([]byte, int(size)-len(contents))...)
+	})
+	return nil
+}
+
+func (file *crashFile) apply(write func(contents []byte) []byte) {
+	file.contents = write(file.contents)
+	file.writes = append(file.writes, write)
+}
+
+func (file *crashFile) Sync() error {
+	file.synced = append([]byte{}, file.contents...)
+	file.writes = nil
+	return nil
+}
+
+func (file *crashFile) Close() error {
+	return nil
+}
+
+func (file *crashFile) Stat() (os.FileInfo, error) {
+	return crashFileInfo{size: int64(len(file.contents))}, nil
+}
+
+// crash returns contents after a power failure, where a random subset of writes since the last sync reached the disk.
+func (file *crashFile) crash(random *rand.Rand) []byte {
+	contents := append([]byte{}, file.synced...)
+	for _, i := range random.Perm(len(file.writes)) {
+		if random.Intn(2) == 0 {
+			contents = file.writes[i](contents)
+		}
+	}
+	return contents
+}
+
+func TestSync(t *testing.T) {
+	policies := map[string]Option{
+		"operation": WithSync(SYNC_OPERATION),
+		"write":     WithSync(SYNC_WRITE),
+		"interval":  WithSyncInterval(10 * time.Millisecond),
+		"never":     WithSync(SYNC_NEVER),
+	}
+	for name, policy := range policies {
+		for _, journal := range []string{"WAL", "copy-on-write"} {
+			t.Run(fmt.Sprintf("Put -> Delete -> Reopen with sync %s and %s", name, journal), func(t *testing.T) {
+				path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+				opts := []Option{policy}
+				if journal == "copy-on-write" {
+					opts = append(opts, WithCopyOnWrite())
+				}
+
+				btree, err := New[Sample](path, 3, opts...)
+				if err != nil {
+					t.Fatalf("Error should not be raised")
+				}
+				for i := 0; i < 200; i++ {
+					btree.Put(&Sample{Int: i})
+				}
+				for i := 0; i < 200; i += 2 {
+					btree.Delete(KeyType(i))
+				}
+				time.Sleep(30 * time.Millisecond)
+				if err = btree.Close(); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+
+				btree, _ = New[Sample](path, 3, opts...)
+				defer btree.Close()
+				for i := 0; i < 200; i++ {
+					if _, err := btree.Get(KeyType(i)); (err == nil) != (i%2 == 1) {
+						t.Errorf("Committed operations should be persisted")
+					}
+				}
+				checkTree(t, btree)
+			})
+		}
+	}
+	for _, journal := range []string{"WAL", "copy-on-write"} {
+		t.Run(fmt.Sprintf("Power failure with sync interval and %s", journal), func(t *testing.T) {
+			for seed := int64(0); seed < 30; seed++ {
+				path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+				opts := []Option{WithSyncInterval(time.Hour)}
+				if journal == "copy-on-write" {
+					opts = append(opts, WithCopyOnWrite())
+				}
+
+				btree, err := New[Sample](path, 3, opts...)
+				if err != nil {
+					t.Fatalf("Error should not be raised")
+				}
+				close(btree.stopFlusher)
+				dataFile, walFile := btree.fp, btree.wal
+				contents, _ := os.ReadFile(path)
+				crashData := newCrashFile(contents)
+				btree.fp = crashData
+				crashWAL := newCrashFile(nil)
+				if walFile != nil {
+					contents, _ = os.ReadFile(path + WAL_PATH_SUFFIX)
+					crashWAL = newCrashFile(contents)
+					btree.wal = &wal{fp: crashWAL}
+				}
+
+				// Keys after each operation, where operations before the last flush should survive the failure
+				random := rand.New(rand.NewSource(seed))
+				states := [][]KeyType{{}}
+				state := map[KeyType]bool{}
+				flushed := 0
+				for i := 0; i < 300; i++ {
+					key := KeyType(random.Intn(100))
+					if random.Intn(3) == 0 {
+						btree.Delete(key)
+						delete(state, key)
+					} else {
+						btree.Put(&Sample{Int: int(key)})
+						state[key] = true
+					}
+					keys := []KeyType{}
+					for key := KeyType(0); key < 100; key++ {
+						if state[key] {
+							keys = append(keys, key)
+						}
+					}
+					states = append(states, keys)
+					if random.Intn(50) == 0 {
+						btree.flush()
+						flushed = len(states) - 1
+					}
+				}
+
+				os.WriteFile(path, crashData.crash(random), 0660)
+				dataFile.Close()
+				if walFile != nil {
+					os.WriteFile(path+WAL_PATH_SUFFIX, crashWAL.crash(random), 0660)
+					walFile.close()
+				}
+
+				btree, err = New[Sample](path, 3, opts...)
+				if err != nil {
+					t.Fatalf("Error should not be raised with seed %d: %v", seed, err)
+				}
+				keys := checkTree(t, btree)
+				isRecovered := false
+				for _, expected := range states[flushed:] {
+					isRecovered = isRecovered || fmt.Sprint(keys) == fmt.Sprint(expected)
+				}
+				if !isRecovered {
+					t.Errorf("Tree should be recovered to a state after the last flush with seed %d", seed)
+				}
+				btree.Close()
+			}
+		})
+	}
+	t.Run("Flusher is stopped by Close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE, WithSync(SYNC_INTERVAL))
+		if btree.stopFlusher == nil {
+			t.Fatalf("Flusher should be started")
+		}
+		btree.Close()
+		select {
+		case <-btree.stopFlusher:
+		default:
+			t.Errorf("Flusher should be stopped")
+		}
+	})
+	t.Run("Invalid sync policy", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		if _, err := New[Sample](path, DEFAULT_DEGREE, WithSync(SYNC_NEVER+1)); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := New[Sample](path, DEFAULT_DEGREE, WithSyncInterval(-time.Second)); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Tx keeps pages written by Put and Delete in a fork of the tree until Commit, so that they are applied
+// atomically and are not visible through the tree. Only one transaction can be in progress at a time,
+// and a Tx should not be shared between goroutines.
+type Tx[T Item] struct {
+	btree      *BTree[T]
+	tree       *BTree[T]
+	isDone     bool
+	err        error
+	savepoints []*savepoint
+}
+
+// savepoint holds the state of the fork when it was taken. Pages written after it are restored by
+// replacing the dirty pages, since pages are never modified in place after they are written.
+type savepoint struct {
+	name           string
+	header         header
+	endOffset      OffsetType
+	dirtyPages     map[OffsetType][]byte
+	allocatedPages map[OffsetType]bool
+	freePages      []OffsetType
+	pendingFrees   []OffsetType
+}
+
+// Begin starts a transaction after a transaction in progress is finished. Put, Delete, Compact and
+// IncrementalVacuum of the tree wait until the transaction is finished, so they should not be called
+// by the goroutine which holds it.
+func (btree *BTree[T]) Begin() (*Tx[T], error) {
+	if btree.isReadOnly {
+		return nil, &ReadOnlyError{Operation: "Begin"}
+	}
+	btree.writeLock.Lock()
+	if !btree.isOpen {
+		btree.writeLock.Unlock()
+		return nil, errors.New("Tree is closed")
+	}
+	tx := new(Tx[T])
+	tx.btree = btree
+	tx.tree = btree.fork()
+	return tx, nil
+}
+
+// Get returns the item including changes made by the transaction.
+func (tx *Tx[T]) Get(key KeyType) (*T, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	return tx.tree.get(key)
+}
+
+func (tx *Tx[T]) Put(item *T) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if err := isValidStringLength(item); err != nil {
+		return err
+	}
+	return tx.run(func(tree *BTree[T]) error {
+		return tree.put(item)
+	})
+}
+
+func (tx *Tx[T]) Delete(key KeyType) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	return tx.run(func(tree *BTree[T]) error {
+		return tree.remove(key)
+	})
+}
+
+// Commit applies every change made by the transaction atomically.
+func (tx *Tx[T]) Commit() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	defer tx.finish()
+	return tx.btree.commitFork(tx.tree)
+}
+
+// Rollback discards every change made by the transaction. It can be called after a failure.
+func (tx *Tx[T]) Rollback() error {
+	if tx.isDone {
+		return errors.New("Transaction is already finished")
+	}
+	tx.finish()
+	return nil
+}
+
+// Savepoint marks the current state of the transaction so that later changes can be undone by RollbackTo.
+// A savepoint with the same name as an earlier one hides it until it is rolled back.
+func (tx *Tx[T]) Savepoint(name string) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	savepoint := tx.tree.captureSavepoint()
+	savepoint.name = name
+	tx.savepoints = append(tx.savepoints, savepoint)
+	return nil
+}
+
+// RollbackTo discards changes made after the savepoint and savepoints taken after it, while keeping the savepoint itself.
+// It also recovers the transaction from a failure after the savepoint.
+func (tx *Tx[T]) RollbackTo(name string) error {
+	if !tx.btree.isOpen {
+		return errors.New("Tree is closed")
+	}
+	if tx.isDone {
+		return errors.New("Transaction is already finished")
+	}
+	index := len(tx.savepoints) - 1
+	for index >= 0 && tx.savepoints[index].name != name {
+		index -= 1
+	}
+	if index < 0 {
+		return errors.New(fmt.Sprintf("Savepoint %s is not found", name))
+	}
+	tx.savepoints = tx.savepoints[:index+1]
+	tx.tree.restoreSavepoint(tx.savepoints[index])
+	tx.err = nil
+	return nil
+}
+
+// captureSavepoint returns the current state of the fork.
+func (btree *BTree[T]) captureSavepoint() *savepoint {
+	savepoint := new(savepoint)
+	savepoint.header = *btree.header
+	savepoint.endOffset = btree.endOffset
+	savepoint.dirtyPages = copyMap(btree.dirtyPages)
+	savepoint.allocatedPages = copyMap(btree.allocatedPages)
+	savepoint.freePages = append([]OffsetType{}, btree.freePages...)
+	savepoint.pendingFrees = append([]OffsetType{}, btree.pendingFrees...)
+	return savepoint
+}
+
+// restoreSavepoint discards changes made to the fork after the savepoint, which can be restored again.
+func (btree *BTree[T]) restoreSavepoint(savepoint *savepoint) {
+	*btree.header = savepoint.header
+	btree.endOffset = savepoint.endOffset
+	btree.dirtyPages = copyMap(savepoint.dirtyPages)
+	btree.allocatedPages = copyMap(savepoint.allocatedPages)
+	btree.freePages = append([]OffsetType{}, savepoint.freePages...)
+	btree.pendingFrees = append([]OffsetType{}, savepoint.pendingFrees...)
+}
+
+// run applies fn to the fork. Pages written before fn fails can not be undone, so the transaction
+// can only be rolled back after that, while a failure without writes such as a missing key is harmless.
+func (tx *Tx[T]) run(fn func(tree *BTree[T]) error) error {
+	writeCount, header, endOffset := tx.tree.writeCount, *tx.tree.header, tx.tree.endOffset
+	if err := fn(tx.tree); err != nil {
+		if tx.tree.writeCount != writeCount || *tx.tree.header != header || tx.tree.endOffset != endOffset {
+			tx.err = err
+		}
+		return err
+	}
+	return nil
+}
+
+func (tx *Tx[T]) check() error {
+	if !tx.btree.isOpen {
+		return errors.New("Tree is closed")
+	}
+	if tx.isDone {
+		return errors.New("Transaction is already finished")
+	}
+	if tx.err != nil {
+		return errors.New("Transaction should be rolled back after failure: " + tx.err.Error())
+	}
+	return nil
+}
+
+func (tx *Tx[T]) finish() {
+	tx.isDone = true
+	tx.btree.writeLock.Unlock()
+}
+
+func copyMap[V any](m map[OffsetType]V) map[OffsetType]V {
+	copied := make(map[OffsetType]V, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
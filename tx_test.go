@@ -0,0 +1,296 @@
+package btree
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTx(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("Commit -> Reopen with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, err := New[Sample](path, 3, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			for i := 0; i < 100; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+
+			tx, err := btree.Begin()
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			for i := 100; i < 200; i++ {
+				if err = tx.Put(&Sample{Int: i}); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			for i := 0; i < 50; i++ {
+				if err = tx.Delete(KeyType(i)); err != nil {
+					t.Errorf("Error should not be raised")
+				}
+			}
+			if item, err := tx.Get(150); err != nil || item.Int != 150 {
+				t.Errorf("Item put by transaction should be found in it")
+			}
+			if _, err = tx.Get(10); err == nil {
+				t.Errorf("Item deleted by transaction should not be found in it")
+			}
+			if _, err = btree.Get(150); err == nil {
+				t.Errorf("Item put by transaction should not be found before commit")
+			}
+			if _, err = btree.Get(10); err != nil {
+				t.Errorf("Item deleted by transaction should be found before commit")
+			}
+
+			if err = tx.Commit(); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			btree.Close()
+
+			btree, err = New[Sample](path, 3, opts...)
+			if err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			defer btree.Close()
+			for i := 0; i < 200; i++ {
+				_, err = btree.Get(KeyType(i))
+				if i < 50 && err == nil {
+					t.Errorf("Deleted item should not be found")
+				} else if i >= 50 && err != nil {
+					t.Errorf("Item should be found")
+				}
+			}
+			checkTree(t, btree)
+		})
+		t.Run(fmt.Sprintf("Rollback with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, 3, opts...)
+			defer btree.Close()
+			for i := 0; i < 100; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+			pages := readPages(t, btree)
+
+			tx, _ := btree.Begin()
+			for i := 0; i < 100; i++ {
+				tx.Delete(KeyType(i))
+				tx.Put(&Sample{Int: i + 100})
+			}
+			if err := tx.Rollback(); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			if len(readPages(t, btree)) != len(pages) {
+				t.Errorf("Data file should not be modified")
+			}
+			for i := 0; i < 200; i++ {
+				if _, err := btree.Get(KeyType(i)); (err == nil) != (i < 100) {
+					t.Errorf("Changes of transaction should be discarded")
+				}
+			}
+
+			if err := btree.Put(&Sample{Int: 300}); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			checkTree(t, btree)
+		})
+	}
+	t.Run("Writers wait for transaction", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		tx, _ := btree.Begin()
+		tx.Put(&Sample{Int: 1})
+		done := make(chan error)
+		go func() {
+			done <- btree.Put(&Sample{Int: 1, String: "later"})
+		}()
+		go func() {
+			tx, err := btree.Begin()
+			if err == nil {
+				err = tx.Rollback()
+			}
+			done <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-done:
+			t.Errorf("Writer should wait until transaction is finished")
+		default:
+		}
+		if _, err := btree.Get(1); err == nil {
+			t.Errorf("Item of transaction in progress should not be found")
+		}
+		tx.Commit()
+
+		for i := 0; i < 2; i++ {
+			if err := <-done; err != nil {
+				t.Errorf("Error should not be raised")
+			}
+		}
+		if item, err := btree.Get(1); err != nil || item.String != "later" {
+			t.Errorf("Item should be updated after transaction")
+		}
+	})
+	t.Run("Finished transaction can not be used", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		tx, _ := btree.Begin()
+		tx.Put(&Sample{Int: 1})
+		tx.Commit()
+		if err := tx.Put(&Sample{Int: 2}); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if _, err := tx.Get(1); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if err := tx.Commit(); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if err := tx.Rollback(); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+	t.Run("Missing key does not fail transaction", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		tx, _ := btree.Begin()
+		tx.Put(&Sample{Int: 1})
+		if err := tx.Delete(2); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if err := tx.Commit(); err != nil {
+			t.Errorf("Error should not be raised")
+		}
+		if _, err := btree.Get(1); err != nil {
+			t.Errorf("Item should be found")
+		}
+	})
+}
+
+func TestSavepoint(t *testing.T) {
+	optionSets := map[string][]Option{
+		"WAL":           nil,
+		"copy-on-write": {WithCopyOnWrite()},
+		"B+tree":        {WithBPlusTree()},
+	}
+	for name, opts := range optionSets {
+		t.Run(fmt.Sprintf("RollbackTo -> Commit with %s", name), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+			btree, _ := New[Sample](path, 3, opts...)
+			for i := 0; i < 50; i++ {
+				btree.Put(&Sample{Int: i})
+			}
+
+			tx, _ := btree.Begin()
+			for i := 50; i < 100; i++ {
+				tx.Put(&Sample{Int: i})
+			}
+			if err := tx.Savepoint("first"); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			for i := 0; i < 100; i += 2 {
+				tx.Delete(KeyType(i))
+			}
+			tx.Savepoint("second")
+			for i := 100; i < 150; i++ {
+				tx.Put(&Sample{Int: i})
+			}
+
+			if err := tx.RollbackTo("first"); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			if err := tx.RollbackTo("second"); err == nil {
+				t.Errorf("Savepoint taken after rolled back one should be removed")
+			}
+			for i := 150; i < 200; i++ {
+				tx.Put(&Sample{Int: i})
+			}
+			// The savepoint is kept and can be rolled back to again
+			if err := tx.RollbackTo("first"); err != nil {
+				t.Errorf("Error should not be raised")
+			}
+			tx.Put(&Sample{Int: 200})
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("Error should not be raised")
+			}
+			btree.Close()
+
+			btree, _ = New[Sample](path, 3, opts...)
+			defer btree.Close()
+			for i := 0; i <= 200; i++ {
+				_, err := btree.Get(KeyType(i))
+				if (i < 100 || i == 200) && err != nil {
+					t.Errorf("Item before savepoint should be found")
+				} else if i >= 100 && i < 200 && err == nil {
+					t.Errorf("Item after savepoint should not be found")
+				}
+			}
+			checkTree(t, btree)
+		})
+	}
+	t.Run("Savepoint with same name", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		tx, _ := btree.Begin()
+		tx.Savepoint("batch")
+		tx.Put(&Sample{Int: 1})
+		tx.Savepoint("batch")
+		tx.Put(&Sample{Int: 2})
+		tx.RollbackTo("batch")
+		if _, err := tx.Get(1); err != nil {
+			t.Errorf("Item before latest savepoint should be found")
+		}
+		if _, err := tx.Get(2); err == nil {
+			t.Errorf("Item after latest savepoint should not be found")
+		}
+		if err := tx.RollbackTo("unknown"); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		tx.Rollback()
+	})
+	t.Run("RollbackTo recovers failed transaction", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+
+		tx, _ := btree.Begin()
+		tx.Put(&Sample{Int: 1})
+		tx.Savepoint("batch")
+		tx.err = errors.New("Failed to write")
+		if err := tx.Put(&Sample{Int: 2}); err == nil {
+			t.Errorf("Error should be raised")
+		}
+		tx.RollbackTo("batch")
+		if err := tx.Commit(); err != nil {
+			t.Errorf("Error should not be raised")
+		}
+		if _, err := btree.Get(1); err != nil {
+			t.Errorf("Item should be found")
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/exp/slices"
+)
+
+// Pages written by an operation are kept in memory until the operation finishes, then logged to the WAL
+// and applied to the data file. Since the WAL is synced before the data file is modified, a crash leaves
+// either a complete log which is replayed by New or an incomplete one which is discarded.
+// Disk layout of WAL record: {recordType}{offset}{length}{data}{checksum}
+// An operation is logged as page records followed by a commit record whose offset is the data file size.
+// With SYNC_INTERVAL, operations are appended until the data file is synced by the flusher.
+type wal struct {
+	fp   file
+	size int64
+}
+
+type walRecord struct {
+	recordType byte
+	offset     OffsetType
+	data       []byte
+}
+
+func openWAL(path string) (*wal, error) {
+	fp, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0660)
+	if err != nil {
+		return nil, errors.New("Failed to open or create WAL file at " + path)
+	}
+	wal := new(wal)
+	wal.fp = fp
+	return wal, nil
+}
+
+// write logs pages and the commit record after the operations logged since the last reset.
+func (wal *wal) write(pages map[OffsetType][]byte, endOffset OffsetType) error {
+	buff := []byte{}
+	for _, offset := range sortedOffsets(pages) {
+		buff = append(buff, serializeWALRecord(WAL_RECORD_PAGE, offset, pages[offset])...)
+	}
+	buff = append(buff, serializeWALRecord(WAL_RECORD_COMMIT, endOffset, nil)...)
+
+	if wal.size == 0 {
+		// Records left by a failed reset should not be read after the new ones
+		if err := wal.fp.Truncate(0); err != nil {
+			return err
+		}
+	}
+	if _, err := wal.fp.WriteAt(buff, wal.size); err != nil {
+		return err
+	}
+	wal.size += int64(len(buff))
+	return nil
+}
+
+// read returns pages and the data file size of the logged operations, where later operations override earlier ones.
+// isCommitted is false when the WAL is empty or no operation was logged completely.
+func (wal *wal) read() (pages map[OffsetType][]byte, endOffset OffsetType, isCommitted bool, err error) {
+	file, err := wal.fp.Stat()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	buff := make([]byte, file.Size())
+	if _, err = wal.fp.ReadAt(buff, 0); err != nil {
+		return nil, 0, false, err
+	}
+	pages = map[OffsetType][]byte{}
+	operation := map[OffsetType][]byte{}
+	for len(buff) > 0 {
+		record, size := deserializeWALRecord(buff)
+		if record == nil {
+			break
+		}
+		buff = buff[size:]
+		if record.recordType != WAL_RECORD_COMMIT {
+			operation[record.offset] = record.data
+			continue
+		}
+		// Pages truncated by the operation are not written even if an earlier operation logged them
+		for offset := range pages {
+			if offset >= record.offset {
+				delete(pages, offset)
+			}
+		}
+		for offset, data := range operation {
+			pages[offset] = data
+		}
+		operation = map[OffsetType][]byte{}
+		endOffset = record.offset
+		isCommitted = true
+	}
+	if !isCommitted {
+		return nil, 0, false, nil
+	}
+	return pages, endOffset, true, nil
+}
+
+func (wal *wal) reset() error {
+	wal.size = 0
+	return wal.fp.Truncate(0)
+}
+
+func (wal *wal) close() error {
+	return wal.fp.Close()
+}
+
+func serializeWALRecord(recordType byte, offset OffsetType, data []byte) []byte {
+	buff := make([]byte, 0, WAL_RECORD_HEADER_SIZE_BYTE+len(data)+CHECKSUM_SIZE_BYTE)
+	buff = append(buff, recordType)
+	buff = appendUint64(buff, uint64(offset))
+	buff = appendUint32(buff, uint32(len(data)))
+	buff = append(buff, data...)
+	return appendChecksum(buff)
+}
+
+// deserializeWALRecord returns nil if buff does not start with a complete record.
+func deserializeWALRecord(buff []byte) (*walRecord, int) {
+	if len(buff) < WAL_RECORD_HEADER_SIZE_BYTE+CHECKSUM_SIZE_BYTE {
+		return nil, 0
+	}
+	length := int(binary.BigEndian.Uint32(buff[9:13]))
+	size := WAL_RECORD_HEADER_SIZE_BYTE + length + CHECKSUM_SIZE_BYTE
+	if len(buff) < size || !verifyChecksum(buff[:size]) {
+		return nil, 0
+	}
+	record := new(walRecord)
+	record.recordType = buff[0]
+	record.offset = OffsetType(binary.BigEndian.Uint64(buff[1:9]))
+	record.data = buff[WAL_RECORD_HEADER_SIZE_BYTE : WAL_RECORD_HEADER_SIZE_BYTE+length]
+	return record, size
+}
+
+func sortedOffsets(pages map[OffsetType][]byte) []OffsetType {
+	offsets := make([]OffsetType, 0, len(pages))
+	for offset := range pages {
+		offsets = append(offsets, offset)
+	}
+	slices.Sort(offsets)
+	return offsets
+}
+
+// operate runs fn on a fork of the tree as a single operation whose page writes are applied atomically.
+// When fn or commit fails, the fork is discarded so that the tree is left unchanged.
+// Callers should hold writeLock, and readers are blocked only while the fork is committed.
+func (btree *BTree[T]) operate(fn func(tree *BTree[T]) error) error {
+	tree := btree.fork()
+	if err := fn(tree); err != nil {
+		return err
+	}
+	return btree.commitFork(tree)
+}
+
+// fork returns a tree which shares the data file with btree but keeps its own header, free pages and
+// pages written until they are committed, so that pending writes are not visible through btree.
+func (btree *BTree[T]) fork() *BTree[T] {
+	tree := new(BTree[T])
+	tree.path = btree.path
+	tree.isOpen = btree.isOpen
+	tree.degree = btree.degree
+	tree.pageSize = btree.pageSize
+	tree.layout = btree.layout
+	tree.journal = btree.journal
+	tree.isDirect = btree.isDirect
+	tree.syncPolicy = btree.syncPolicy
+	tree.fp = btree.fp
+	tree.wal = btree.wal
+	tree.snapshots = btree.snapshots
+	tree.latches = btree.latches
+	header := *btree.header
+	tree.header = &header
+	tree.endOffset = btree.endOffset
+	tree.freePages = append([]OffsetType{}, btree.freePages...)
+	tree.heldFrees = btree.heldFrees
+	tree.syncedTxid = btree.syncedTxid
+	tree.committedEndOffset = btree.committedEndOffset
+	tree.operation = newOperation[T]()
+	if tree.isCopyOnWrite() && !tree.isDirect {
+		tree.cleanPages = map[OffsetType][]byte{}
+	}
+	return tree
+}
+
+// commitFork commits pages written by the fork and takes over its state.
+func (btree *BTree[T]) commitFork(tree *BTree[T]) error {
+	btree.lock.Lock()
+	defer btree.lock.Unlock()
+	err := tree.commit()
+	btree.isOpen = tree.isOpen
+	if err != nil {
+		return err
+	}
+	btree.commitCount += 1
+	btree.header = tree.header
+	btree.endOffset = tree.endOffset
+	btree.freePages = tree.freePages
+	btree.heldFrees = tree.heldFrees
+	btree.syncedTxid = tree.syncedTxid
+	btree.committedEndOffset = tree.committedEndOffset
+	return nil
+}
+
+// commit logs dirty pages to the WAL and applies them to the data file.
+func (btree *BTree[T]) commit() error {
+	if btree.isDirect {
+		return btree.applyPages(nil, btree.endOffset)
+	}
+	if btree.isCopyOnWrite() {
+		return btree.commitCopyOnWrite()
+	}
+	if err := btree.wal.write(btree.dirtyPages, btree.endOffset); err != nil {
+		return err
+	}
+	if err := btree.syncBarrier(btree.wal.fp); err != nil {
+		return err
+	}
+	err := btree.applyPages(btree.dirtyPages, btree.endOffset)
+	btree.operation = newOperation[T]()
+	if err != nil {
+		// The WAL is kept so that the operation is replayed when the tree is opened again
+		btree.fp.Close()
+		btree.wal.close()
+		btree.isOpen = false
+		return errors.New("Failed to write data file, tree should be opened again to recover from WAL: " + err.Error())
+	}
+	// With SYNC_INTERVAL, the WAL is kept until the flusher syncs the data file.
+	// Replaying the applied operation is harmless, so the WAL left by a failed reset is overwritten by the next one.
+	if btree.syncPolicy != SYNC_INTERVAL {
+		btree.wal.reset()
+	}
+	return nil
+}
+
+// recover replays the operation logged in the WAL if it was committed, and discards it otherwise.
+func (btree *BTree[T]) recover() error {
+	pages, endOffset, isCommitted, err := btree.wal.read()
+	if err != nil {
+		return err
+	}
+	if isCommitted {
+		if err = btree.applyPages(pages, endOffset); err != nil {
+			return err
+		}
+		// The data file is synced regardless of the sync policy since the WAL is discarded
+		if err = btree.fp.Sync(); err != nil {
+			return err
+		}
+	}
+	return btree.wal.reset()
+}
+
+// applyPages writes pages to the data file, resizes it to endOffset and syncs it according to the sync policy.
+// Pages beyond endOffset, which are truncated by vacuum, are not written.
+func (btree *BTree[T]) applyPages(pages map[OffsetType][]byte, endOffset OffsetType) error {
+	btree.preservePages(sortedOffsets(pages), endOffset)
+	for _, offset := range sortedOffsets(pages) {
+		if offset >= endOffset {
+			continue
+		}
+		if err := btree.writePage(pages[offset], offset); err != nil {
+			return err
+		}
+	}
+	if err := btree.fp.Truncate(endOffset); err != nil {
+		return err
+	}
+	return btree.syncFile(btree.fp)
+}
+
+// readAt reads a page or the beginning of a page at offset, including pages written by the current operation.
+func (btree *BTree[T]) readAt(buff []byte, offset OffsetType) error {
+	if btree.operation == nil {
+		_, err := btree.fp.ReadAt(buff, offset)
+		return err
+	}
+	if page, ok := btree.dirtyPages[offset]; ok {
+		if copy(buff, page) < len(buff) {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	}
+	if _, err := btree.fp.ReadAt(buff, offset); err != nil {
+		return err
+	}
+	if btree.cleanPages != nil && len(buff) == btree.pageSize {
+		btree.cleanPages[offset] = append([]byte{}, buff...)
+	}
+	return nil
+}
+
+// writeAt writes a page at offset, which is kept in memory until the current operation is committed.
+// Trees with direct writes, such as the one built by Compact, write the page to the data file immediately.
+func (btree *BTree[T]) writeAt(buff []byte, offset OffsetType) error {
+	if err := btree.checkLatch(offset); err != nil {
+		return err
+	}
+	if btree.isDirect {
+		_, err := btree.fp.WriteAt(buff, offset)
+		return err
+	}
+	btree.dirtyPages[offset] = buff
+	btree.writeCount += 1
+	return nil
+}
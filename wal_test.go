@@ -0,0 +1,100 @@
+package btree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// crashAfterLogging logs items to the WAL and closes the tree without applying them to the data file.
+func crashAfterLogging(t *testing.T, btree *BTree[Sample], keys []int) {
+	tree := btree.fork()
+	for _, key := range keys {
+		if err := tree.put(&Sample{Int: key}); err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+	}
+	if err := tree.wal.write(tree.dirtyPages, tree.endOffset); err != nil {
+		t.Fatalf("Error should not be raised")
+	}
+	btree.close()
+}
+
+func TestWAL(t *testing.T) {
+	t.Run("Committed operation is replayed on open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		for i := 0; i < 50; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		keys := []int{}
+		for i := 50; i < 100; i++ {
+			keys = append(keys, i)
+		}
+		crashAfterLogging(t, btree, keys)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		if keys := checkTree(t, btree); len(keys) != 100 {
+			t.Errorf("Logged items should be replayed")
+		}
+		if wal, _ := os.Stat(path + WAL_PATH_SUFFIX); wal.Size() != 0 {
+			t.Errorf("WAL should be empty after recovery")
+		}
+	})
+	t.Run("Incomplete operation is discarded on open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		for i := 0; i < 50; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+		crashAfterLogging(t, btree, []int{50, 51, 52, 53, 54})
+
+		// Lose the commit record as if the tree crashed while writing the WAL
+		wal, _ := os.Stat(path + WAL_PATH_SUFFIX)
+		os.Truncate(path+WAL_PATH_SUFFIX, wal.Size()-1)
+
+		btree, err := New[Sample](path, DEFAULT_DEGREE)
+		if err != nil {
+			t.Fatalf("Error should not be raised")
+		}
+		defer btree.Close()
+
+		if keys := checkTree(t, btree); len(keys) != 50 {
+			t.Errorf("Incomplete operation should be discarded")
+		}
+		if _, err = btree.Get(50); err == nil {
+			t.Errorf("Error should be raised")
+		}
+	})
+	t.Run("Failed operation is rolled back", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DEFAULT_DATA_PATH)
+
+		btree, _ := New[Sample](path, DEFAULT_DEGREE)
+		defer btree.Close()
+		for i := 0; i < 50; i++ {
+			btree.Put(&Sample{Int: i})
+		}
+
+		header := *btree.header
+		err := btree.operate(func(tree *BTree[Sample]) error {
+			tree.put(&Sample{Int: 100})
+			return tree.remove(200)
+		})
+		if err == nil {
+			t.Errorf("Error should be raised")
+		}
+		if *btree.header != header || btree.operation != nil {
+			t.Errorf("Header and pages should be restored")
+		}
+		if _, err = btree.Get(100); err == nil {
+			t.Errorf("Item put by failed operation should not be found")
+		}
+	})
+}